@@ -1,11 +1,18 @@
 package main
 
 import (
+	"log"
+	"my-go-project/internal/common"
 	"my-go-project/internal/delivery/http"
 	"my-go-project/internal/repository/postgres"
 	"my-go-project/internal/usecase"
 	"my-go-project/pkg/cache"
 	config "my-go-project/pkg/database"
+	"my-go-project/pkg/mailer"
+	"my-go-project/pkg/storage"
+	"my-go-project/pkg/token"
+	"os"
+	"time"
 
 	_ "my-go-project/docs" // Import generated docs
 
@@ -23,10 +30,23 @@ import (
 // @name Authorization
 
 func main() {
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: common.ErrorHandlerFiber,
+		// Bodies over the default limit arrive as a stream rather than being
+		// refused, so the image upload route can take more; LimitBody holds
+		// every other route to the default. Multipart forms are parsed only
+		// by the handlers that ask for them, after their own size checks.
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
+	app.Use(common.LimitBody(fiber.DefaultBodyLimit, http.IsImageUpload))
 	db := config.InitDB()
 	cache.InitRedis()
+	token.InitKeys()
+	tokenStore := cache.NewTokenStore(cache.RedisClient)
+	common.TokenRevocations = tokenStore
 	app.Get("/swagger/*", fiberSwagger.WrapHandler)
+	http.NewJWKSHandler(app)
 
 	// Task handlers
 	taskRepo := postgres.NewTaskRepository(db)
@@ -41,13 +61,44 @@ func main() {
 
 	// User handlers
 	userRepo := postgres.NewUserPostgresRepo(db)
-	userUC := usecase.NewUserUsecase(userRepo)
+	loginAttempts := cache.NewLoginAttemptStore(cache.RedisClient)
+	userUC := usecase.NewUserUsecase(userRepo, tokenStore, loginAttempts, mailer.NewFromEnv(), getEnv("APP_URL", "http://localhost:3000"))
 	http.NewUserHandler(app, userUC)
 
+	// Create the first admin account from the environment, if configured
+	if email := os.Getenv("ADMIN_EMAIL"); email != "" {
+		if err := userUC.BootstrapAdmin(email, os.Getenv("ADMIN_PASSWORD")); err != nil {
+			log.Fatal("Failed to bootstrap admin user:", err)
+		}
+	}
+
 	// Product handlers
 	productRepo := postgres.NewProductRepository(db)
-	productUC := usecase.NewProductUseCase(productRepo)
+	categoryRepo := postgres.NewCategoryRepository(db)
+	attributeRepo := postgres.NewAttributeRepository(db)
+	productUC := usecase.NewProductUseCase(productRepo, categoryRepo, attributeRepo)
 	http.NewProductHandler(app, productUC)
+	http.NewVariantHandler(app, productUC)
+	http.NewImportHandler(app, productUC)
+
+	// Product image handlers
+	imageRepo := postgres.NewProductImageRepository(db)
+	imageUC := usecase.NewImageUseCase(imageRepo, productRepo, storage.NewFromEnv(), getEnv("IMAGE_BASE_URL", "/v1/images"))
+	http.NewImageHandler(app, imageUC)
+
+	// Price history and scheduled price changes
+	priceRepo := postgres.NewPriceRepository(db)
+	priceUC := usecase.NewPriceUseCase(priceRepo, productRepo)
+	http.NewPriceHandler(app, priceUC)
+	go priceUC.RunScheduler(time.Minute)
+
+	// Category handlers
+	categoryUC := usecase.NewCategoryUseCase(categoryRepo)
+	http.NewCategoryHandler(app, categoryUC)
+
+	// Attribute handlers
+	attributeUC := usecase.NewAttributeUseCase(attributeRepo, categoryRepo, productRepo)
+	http.NewAttributeHandler(app, attributeUC)
 
 	// Cart handlers
 	cartRepo := postgres.NewCartRepository(db)
@@ -56,9 +107,15 @@ func main() {
 
 	// Order handlers
 	orderRepo := postgres.NewOrderRepository(db)
-	orderUC := usecase.NewOrderUseCase(orderRepo, cartRepo, productRepo)
+	uow := postgres.NewUnitOfWork(db)
+	orderUC := usecase.NewOrderUseCase(orderRepo, cartRepo, productRepo, uow)
 	http.NewOrderHandler(app, orderUC)
 
+	// Return handlers
+	returnRepo := postgres.NewReturnRepository(db)
+	returnUC := usecase.NewReturnUseCase(returnRepo, uow)
+	http.NewReturnHandler(app, returnUC)
+
 	// Review handlers
 	reviewRepo := postgres.NewReviewPG(db)
 	reviewUC := usecase.NewReviewUsecase(reviewRepo)
@@ -68,3 +125,10 @@ func main() {
 		panic(err)
 	}
 }
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -1,15 +1,21 @@
 package common
 
 import (
+	"my-go-project/internal/domain"
+	"my-go-project/pkg/token"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-const (
-	SecretKey = "your-secret-key"
-)
+// RevocationChecker is consulted by AuthMiddleware to reject logged-out tokens.
+type RevocationChecker interface {
+	IsAccessTokenRevoked(jti string) (bool, error)
+	RevokedBefore(userID uint) (int64, error)
+}
+
+// TokenRevocations is set at startup; when nil no revocation check is done.
+var TokenRevocations RevocationChecker
 
 func AuthMiddleware(c *fiber.Ctx) error {
 	auth := c.Get("Authorization")
@@ -18,17 +24,59 @@ func AuthMiddleware(c *fiber.Ctx) error {
 	}
 
 	tokenStr := strings.TrimPrefix(auth, "Bearer ")
-	token, err := jwt.Parse(tokenStr, func(_ *jwt.Token) (interface{}, error) {
-		return []byte(SecretKey), nil
-	})
-
-	if err != nil || !token.Valid {
+	// ParseToken rejects tokens whose user_id is missing or not a number
+	claims, err := token.ParseToken(tokenStr, token.TypeAccess)
+	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, "Invalid or expired token")
 	}
 
-	claims := token.Claims.(jwt.MapClaims)
-	userID := uint(claims["user_id"].(float64))
-	c.Locals("user_id", userID)
+	if TokenRevocations != nil {
+		if revoked, err := isRevoked(claims); err != nil {
+			return fiber.NewError(fiber.StatusInternalServerError, "Failed to check token")
+		} else if revoked {
+			return fiber.NewError(fiber.StatusUnauthorized, "Token has been revoked")
+		}
+	}
+
+	c.Locals("user_id", claims.UserID)
+	c.Locals("role", claims.Role)
+	c.Locals("jti", claims.ID)
+	if claims.ExpiresAt != nil {
+		c.Locals("token_exp", claims.ExpiresAt.Time)
+	}
 
 	return c.Next()
 }
+
+// RequireRole must run after AuthMiddleware and only lets the given roles through.
+func RequireRole(roles ...domain.Role) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role, _ := c.Locals("role").(domain.Role)
+		for _, r := range roles {
+			if role == r {
+				return c.Next()
+			}
+		}
+		return fiber.NewError(fiber.StatusForbidden, "Insufficient permissions")
+	}
+}
+
+// CurrentActor returns the authenticated user of the request, for usecases
+// that record who did what. It must run after AuthMiddleware.
+func CurrentActor(c *fiber.Ctx) domain.Actor {
+	userID, _ := c.Locals("user_id").(uint)
+	role, _ := c.Locals("role").(domain.Role)
+	return domain.Actor{UserID: userID, Role: role}
+}
+
+func isRevoked(claims *token.Claims) (bool, error) {
+	revoked, err := TokenRevocations.IsAccessTokenRevoked(claims.ID)
+	if err != nil || revoked {
+		return revoked, err
+	}
+	before, err := TokenRevocations.RevokedBefore(claims.UserID)
+	if err != nil || before == 0 {
+		return false, err
+	}
+	return claims.IssuedAtMilli() < before, nil
+}
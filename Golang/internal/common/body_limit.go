@@ -0,0 +1,41 @@
+package common
+
+import (
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// LimitBody rejects request bodies larger than limit with 413. It is meant
+// for an app with StreamRequestBody set, where fasthttp hands over large
+// and chunked bodies as a stream instead of refusing them, so routes that
+// need more than limit can check the size themselves. Requests for which
+// skip returns true are passed through untouched.
+func LimitBody(limit int, skip func(c *fiber.Ctx) bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		req := c.Request()
+		if !req.IsBodyStream() || (skip != nil && skip(c)) {
+			return c.Next()
+		}
+		if req.Header.ContentLength() > limit {
+			return bodyTooLarge(c)
+		}
+
+		// Chunked bodies have no length up front, so read one byte past the limit
+		body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(limit)+1))
+		if err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Could not read request body")
+		}
+		if len(body) > limit {
+			return bodyTooLarge(c)
+		}
+		req.SetBody(body)
+		return c.Next()
+	}
+}
+
+func bodyTooLarge(c *fiber.Ctx) error {
+	// The rest of the body is still on the connection
+	c.Context().SetConnectionClose()
+	return fiber.NewError(fiber.StatusRequestEntityTooLarge, "Request body too large")
+}
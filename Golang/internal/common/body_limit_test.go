@@ -0,0 +1,66 @@
+package common
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const testBodyLimit = 1024
+
+func newLimitTestApp() *fiber.App {
+	app := fiber.New(fiber.Config{
+		BodyLimit:                    testBodyLimit,
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
+	})
+	app.Use(LimitBody(testBodyLimit, func(c *fiber.Ctx) bool { return c.Path() == "/upload" }))
+	echoLength := func(c *fiber.Ctx) error {
+		return c.SendString(strconv.Itoa(len(c.Body())))
+	}
+	app.Post("/echo", echoLength)
+	app.Post("/upload", echoLength)
+	return app
+}
+
+func TestLimitBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		size    int
+		chunked bool
+		want    int
+	}{
+		{"small body", "/echo", 100, false, fiber.StatusOK},
+		{"body at the limit", "/echo", testBodyLimit, false, fiber.StatusOK},
+		{"body over the limit", "/echo", testBodyLimit + 1, false, fiber.StatusRequestEntityTooLarge},
+		{"small chunked body", "/echo", 100, true, fiber.StatusOK},
+		{"chunked body over the limit", "/echo", 4 * testBodyLimit, true, fiber.StatusRequestEntityTooLarge},
+		{"skipped route", "/upload", 4 * testBodyLimit, false, fiber.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+				req.TransferEncoding = []string{"chunked"}
+			}
+			resp, err := newLimitTestApp().Test(req)
+			if err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.want {
+				t.Fatalf("status %d, want %d: %s", resp.StatusCode, tt.want, body)
+			}
+			if tt.want == fiber.StatusOK && string(body) != strconv.Itoa(tt.size) {
+				t.Errorf("handler read %s bytes, want %d", body, tt.size)
+			}
+		})
+	}
+}
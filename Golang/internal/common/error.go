@@ -1,22 +1,105 @@
 package common
 
 import (
+	"errors"
+	"math"
+	"my-go-project/internal/domain"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
 
+// ErrorHandlerFiber turns every error returned by a handler into the same
+// envelope: {"error": status text, "code": machine-readable code,
+// "message": human message, "fields": [...] for validation errors}.
 func ErrorHandlerFiber(c *fiber.Ctx, err error) error {
-	log.Errorf("Error occurred: %v", err)
 	code := fiber.StatusInternalServerError
+	errCode := "internal_error"
 	msg := "Internal Server Error"
-	if e, ok := err.(*fiber.Error); ok {
-		code = e.Code
-		msg = e.Message
+	var fields []FieldError
+
+	var verr *ValidationError
+	var derr *domain.Error
+	var throttled *domain.LoginThrottledError
+	var locked *domain.AccountLockedError
+	var ferr *fiber.Error
+	switch {
+	case errors.As(err, &verr):
+		code = fiber.StatusUnprocessableEntity
+		errCode = string(domain.ErrorKindValidation)
+		msg = "Validation failed"
+		fields = verr.Fields
+	case errors.As(err, &derr):
+		code = statusForKind(derr.Kind)
+		errCode = derr.Code
+		if errCode == "" {
+			errCode = string(derr.Kind)
+		}
+		msg = derr.Message
+	case errors.As(err, &throttled):
+		code = fiber.StatusTooManyRequests
+		errCode = "too_many_attempts"
+		msg = throttled.Error()
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(throttled.RetryAfter))
+	case errors.As(err, &locked):
+		code = fiber.StatusLocked
+		errCode = "account_locked"
+		msg = locked.Error()
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(locked.RetryAfter))
+	case errors.As(err, &ferr):
+		code = ferr.Code
+		errCode = codeForStatus(code)
+		msg = ferr.Message
+	}
+
+	// Only unexpected errors are logged; their details never reach the client
+	if code >= fiber.StatusInternalServerError {
+		log.Errorf("Error occurred: %v", err)
 	}
-	return c.Status(code).JSON(fiber.Map{
+
+	body := fiber.Map{
 		"error":   http.StatusText(code),
+		"code":    errCode,
 		"message": msg,
-	})
+	}
+	if fields != nil {
+		body["fields"] = fields
+	}
+	return c.Status(code).JSON(body)
+}
+
+func statusForKind(kind domain.ErrorKind) int {
+	switch kind {
+	case domain.ErrorKindNotFound:
+		return fiber.StatusNotFound
+	case domain.ErrorKindConflict, domain.ErrorKindInsufficientStock:
+		return fiber.StatusConflict
+	case domain.ErrorKindValidation:
+		return fiber.StatusUnprocessableEntity
+	case domain.ErrorKindForbidden:
+		return fiber.StatusForbidden
+	case domain.ErrorKindUnauthorized:
+		return fiber.StatusUnauthorized
+	case domain.ErrorKindPreconditionFailed:
+		return fiber.StatusPreconditionFailed
+	default:
+		return fiber.StatusInternalServerError
+	}
+}
+
+// codeForStatus derives a code like "bad_request" from the status text.
+func codeForStatus(code int) string {
+	text := http.StatusText(code)
+	if text == "" {
+		return "error"
+	}
+	return strings.ToLower(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
 }
@@ -11,11 +11,14 @@ type PaginatedResponse struct {
 	Total  int64 `json:"total"`
 	Limit  int   `json:"limit"`
 	Offset int   `json:"offset"`
+	// NextCursor continues cursor pagination; empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func RespondError(c *fiber.Ctx, code int, msg string) error {
 	return c.Status(code).JSON(fiber.Map{
 		"error":   http.StatusText(code),
+		"code":    codeForStatus(code),
 		"message": msg,
 	})
 }
@@ -0,0 +1,152 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gofiber/fiber/v2"
+)
+
+var validate = newValidator()
+
+// FieldError describes one invalid field. Field is the JSON name and Code is
+// the rule that failed, so clients can translate the message themselves.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Fields []FieldError `json:"fields"`
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		msgs[i] = f.Field + " " + f.Message
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return f.Name
+		}
+		return name
+	})
+	if err := v.RegisterValidation("password", validatePassword); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// validatePassword is the password policy: 8 to 72 bytes (bcrypt ignores
+// anything longer) with at least one letter and one digit.
+func validatePassword(fl validator.FieldLevel) bool {
+	pw := fl.Field().String()
+	if len(pw) < 8 || len(pw) > 72 {
+		return false
+	}
+	var letter, digit bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsDigit(r):
+			digit = true
+		}
+	}
+	return letter && digit
+}
+
+// Validate checks the `validate` struct tags of s and returns a
+// *ValidationError listing every invalid field.
+func Validate(s any) error {
+	err := validate.Struct(s)
+	if err == nil {
+		return nil
+	}
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+
+	out := &ValidationError{Fields: make([]FieldError, 0, len(verrs))}
+	for _, fe := range verrs {
+		out.Fields = append(out.Fields, FieldError{
+			Field:   fieldPath(fe),
+			Code:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return out
+}
+
+// BindAndValidate parses the JSON body into out and validates it. It
+// returns a 400 *fiber.Error for a malformed body and a *ValidationError
+// for invalid fields.
+func BindAndValidate(c *fiber.Ctx, out any) error {
+	if err := c.BodyParser(out); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+	}
+	return Validate(out)
+}
+
+// BindQueryAndValidate is BindAndValidate for the query string.
+func BindQueryAndValidate(c *fiber.Ctx, out any) error {
+	if err := c.QueryParser(out); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid query parameters")
+	}
+	return Validate(out)
+}
+
+// fieldPath drops the top-level struct name from the namespace, so nested
+// fields read like "items[0].quantity".
+func fieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if i := strings.Index(ns, "."); i >= 0 {
+		return ns[i+1:]
+	}
+	return ns
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "url":
+		return "must be a valid URL"
+	case "password":
+		return "must be 8 to 72 characters and contain a letter and a digit"
+	case "oneof":
+		return "must be one of: " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "gte":
+		return "must be greater than or equal to " + fe.Param()
+	case "lte":
+		return "must be less than or equal to " + fe.Param()
+	default:
+		return "is invalid"
+	}
+}
@@ -0,0 +1,142 @@
+package http
+
+import (
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AttributeHandler struct {
+	usecase *usecase.AttributeUseCase
+}
+
+func NewAttributeHandler(app *fiber.App, uc *usecase.AttributeUseCase) {
+	handler := &AttributeHandler{usecase: uc}
+
+	// Public routes
+	app.Get("/v1/categories/:id/attributes", handler.GetByCategory)
+
+	// Admin routes (require staff or admin role)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Post("/v1/admin/categories/:id/attributes", common.AuthMiddleware, staff, handler.Create)
+	app.Put("/v1/admin/attributes/:id", common.AuthMiddleware, staff, handler.Update)
+	app.Delete("/v1/admin/attributes/:id", common.AuthMiddleware, staff, handler.Delete)
+	app.Put("/v1/products/:id/attributes", common.AuthMiddleware, staff, handler.SetProductValues)
+}
+
+// AttributeRequest is the body of create and update. A missing code is
+// derived from the name; the type cannot change after creation.
+type AttributeRequest struct {
+	Code      string   `json:"code" validate:"max=50"`
+	Name      string   `json:"name" validate:"required,max=100"`
+	Type      string   `json:"type" validate:"omitempty,oneof=text number boolean enum"`
+	Unit      string   `json:"unit" validate:"max=20"`
+	Options   []string `json:"options" validate:"max=100,dive,max=100"`
+	SortOrder int      `json:"sort_order"`
+}
+
+func (r *AttributeRequest) toAttribute() *domain.Attribute {
+	return &domain.Attribute{
+		Code:      r.Code,
+		Name:      r.Name,
+		Type:      domain.AttributeType(r.Type),
+		Unit:      r.Unit,
+		Options:   r.Options,
+		SortOrder: r.SortOrder,
+	}
+}
+
+// ProductAttributesRequest maps attribute codes to values: numbers for
+// number attributes, true/false for booleans and strings otherwise.
+type ProductAttributesRequest struct {
+	Values map[string]any `json:"values" validate:"max=100"`
+}
+
+func (h *AttributeHandler) GetByCategory(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	attributes, err := h.usecase.GetCategoryAttributes(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(attributes)
+}
+
+func (h *AttributeHandler) Create(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	var req AttributeRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	attribute := req.toAttribute()
+	attribute.CategoryID = uint(id)
+	if err := h.usecase.CreateAttribute(attribute); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(attribute)
+}
+
+func (h *AttributeHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid attribute ID")
+	}
+
+	var req AttributeRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	attribute := req.toAttribute()
+	attribute.ID = uint(id)
+	if err := h.usecase.UpdateAttribute(attribute); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(attribute)
+}
+
+func (h *AttributeHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid attribute ID")
+	}
+
+	if err := h.usecase.DeleteAttribute(uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func (h *AttributeHandler) SetProductValues(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	var req ProductAttributesRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	product, err := h.usecase.SetProductAttributes(uint(id), req.Values)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(product)
+}
@@ -4,6 +4,7 @@ import (
 	"my-go-project/internal/common"
 	"my-go-project/internal/domain"
 	"my-go-project/internal/usecase"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,47 +17,209 @@ type UserHandler struct {
 
 func NewUserHandler(app *fiber.App, uc *usecase.UserUsecase) {
 	handler := &UserHandler{Usecase: uc}
-	app.Post("/v1/users/login", limiter.New(limiter.Config{
+	// Failed logins are throttled per email and per IP by the usecase
+	app.Post("/v1/users/login", handler.Login)
+	app.Post("/v1/users/login/mfa", handler.LoginMFA)
+
+	app.Post("/v1/users/register", handler.Create)
+	app.Post("/v1/users/refresh", handler.Refresh)
+	app.Post("/v1/users/logout", common.AuthMiddleware, handler.Logout)
+	app.Post("/v1/users/logout-all", common.AuthMiddleware, handler.LogoutAll)
+
+	app.Post("/v1/users/password/forgot", limiter.New(limiter.Config{
 		Max:        3,
 		Expiration: 1 * time.Minute,
 		LimitReached: func(_ *fiber.Ctx) error {
-			return fiber.NewError(fiber.StatusTooManyRequests, "Too many login attempts. Please try again later.")
+			return fiber.NewError(fiber.StatusTooManyRequests, "Too many requests. Please try again later.")
 		},
-	}), handler.Login)
+	}), handler.ForgotPassword)
+	app.Post("/v1/users/password/reset", handler.ResetPassword)
+	app.Post("/v1/users/verify-email", handler.VerifyEmail)
+	app.Post("/v1/users/verify-email/resend", common.AuthMiddleware, handler.ResendVerification)
 
-	app.Post("/v1/users/register", handler.Create)
+	app.Get("/v1/users/me", common.AuthMiddleware, handler.GetMe)
+	app.Patch("/v1/users/me", common.AuthMiddleware, handler.UpdateMe)
+
+	app.Post("/v1/users/mfa/totp/enroll", common.AuthMiddleware, handler.EnrollTOTP)
+	app.Post("/v1/users/mfa/totp/confirm", common.AuthMiddleware, handler.ConfirmTOTP)
+	app.Post("/v1/users/mfa/totp/disable", common.AuthMiddleware, handler.DisableTOTP)
+	app.Post("/v1/users/mfa/recovery-codes", common.AuthMiddleware, handler.RegenerateRecoveryCodes)
+
+	// Admin routes
+	admin := common.RequireRole(domain.RoleAdmin)
+	app.Put("/v1/admin/users/:id/role", common.AuthMiddleware, admin, handler.ChangeRole)
+	app.Post("/v1/admin/users/:id/unlock", common.AuthMiddleware, admin, handler.Unlock)
+}
+
+type RegisterRequest struct {
+	Username string `json:"username" validate:"max=50"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Phone    string `json:"phone" validate:"max=20"`
+	Password string `json:"password" validate:"required,password"`
+	Avatar   string `json:"avatar" validate:"omitempty,url"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required"`
+}
+
+type ChangeRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=customer staff admin"`
+}
+
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type UpdateProfileRequest struct {
+	Username *string `json:"username" validate:"omitempty,max=50"`
+	Phone    *string `json:"phone" validate:"omitempty,max=20"`
+	Avatar   *string `json:"avatar" validate:"omitempty,url"`
 }
 
 func (h *UserHandler) Create(c *fiber.Ctx) error {
-	var user domain.User
-	if err := c.BodyParser(&user); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid JSON")
+	var req RegisterRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	if err := user.ValidateLogin(); err != nil {
-		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	user := domain.User{
+		Username: req.Username,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Password: req.Password,
+		Avatar:   req.Avatar,
 	}
 
 	if err := h.Usecase.CreateUser(&user); err != nil {
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		return err
 	}
 
 	return common.RespondCreated(c, user.ID)
 }
 
 func (h *UserHandler) Login(c *fiber.Ctx) error {
-	var user domain.User
-	if err := c.BodyParser(&user); err != nil {
-		return fiber.NewError(fiber.StatusBadRequest, "Invalid JSON")
+	var req LoginRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.Usecase.LoginUser(&domain.User{Email: req.Email, Password: req.Password}, c.IP())
+	if err != nil {
+		return err
+	}
+
+	return common.RespondseSuccess(c, result)
+}
+
+func (h *UserHandler) LoginMFA(c *fiber.Ctx) error {
+	var req MFALoginRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	result, err := h.Usecase.CompleteMFALogin(req.MFAToken, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return common.RespondseSuccess(c, result)
+}
+
+func (h *UserHandler) EnrollTOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	enrollment, err := h.Usecase.EnrollTOTP(userID)
+	if err != nil {
+		return err
+	}
+
+	return common.RespondseSuccess(c, enrollment)
+}
+
+func (h *UserHandler) ConfirmTOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req MFACodeRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := h.Usecase.ConfirmTOTP(userID, req.Code)
+	if err != nil {
+		return err
 	}
 
-	if err := user.ValidateLogin(); err != nil {
-		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	return common.RespondseSuccess(c, fiber.Map{"recovery_codes": codes})
+}
+
+func (h *UserHandler) DisableTOTP(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req MFACodeRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.Usecase.DisableTOTP(userID, req.Code); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
+
+func (h *UserHandler) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req MFACodeRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	codes, err := h.Usecase.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		return err
+	}
+
+	return common.RespondseSuccess(c, fiber.Map{"recovery_codes": codes})
+}
+
+func (h *UserHandler) Refresh(c *fiber.Ctx) error {
+	var req RefreshTokenRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	accessToken, refreshToken, err := h.Usecase.LoginUser(&user)
+	accessToken, refreshToken, err := h.Usecase.RefreshTokens(req.RefreshToken)
 	if err != nil {
-		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+		return err
 	}
 
 	return common.RespondseSuccess(c, fiber.Map{
@@ -64,3 +227,145 @@ func (h *UserHandler) Login(c *fiber.Ctx) error {
 		"refresh_token": refreshToken,
 	})
 }
+
+func (h *UserHandler) Logout(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+	jti, _ := c.Locals("jti").(string)
+	exp, _ := c.Locals("token_exp").(time.Time)
+
+	// The refresh token is optional so clients that lost it can still log out.
+	var req LogoutRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
+		}
+	}
+
+	if err := h.Usecase.Logout(userID, jti, exp, req.RefreshToken); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
+
+func (h *UserHandler) LogoutAll(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.Usecase.LogoutAll(userID); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
+
+func (h *UserHandler) ForgotPassword(c *fiber.Ctx) error {
+	var req ForgotPasswordRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.Usecase.RequestPasswordReset(req.Email); err != nil {
+		return err
+	}
+
+	// Same answer whether or not the email exists
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "If the email is registered, a reset link has been sent",
+	})
+}
+
+func (h *UserHandler) ResetPassword(c *fiber.Ctx) error {
+	var req ResetPasswordRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.Usecase.ResetPassword(req.Token, req.Password); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
+
+func (h *UserHandler) VerifyEmail(c *fiber.Ctx) error {
+	var req VerifyEmailRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.Usecase.VerifyEmail(req.Token); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
+
+func (h *UserHandler) ResendVerification(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	if err := h.Usecase.ResendVerificationEmail(userID); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Verification email sent",
+	})
+}
+
+func (h *UserHandler) GetMe(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	user, err := h.Usecase.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+
+	return common.RespondseSuccess(c, user.Profile())
+}
+
+func (h *UserHandler) UpdateMe(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	var req UpdateProfileRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	user, err := h.Usecase.UpdateProfile(userID, req.Username, req.Phone, req.Avatar)
+	if err != nil {
+		return err
+	}
+
+	return common.RespondseSuccess(c, user.Profile())
+}
+
+func (h *UserHandler) ChangeRole(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	var req ChangeRoleRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	if err := h.Usecase.ChangeRole(uint(id), domain.Role(req.Role)); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
+
+func (h *UserHandler) Unlock(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid user ID")
+	}
+
+	if err := h.Usecase.UnlockAccount(uint(id)); err != nil {
+		return err
+	}
+
+	return common.RespondNoContent(c)
+}
@@ -18,18 +18,20 @@ func NewCartHandler(app *fiber.App, uc *usecase.CartUseCase) {
 	// All cart routes require authentication
 	app.Get("/v1/cart", common.AuthMiddleware, handler.GetCart)
 	app.Post("/v1/cart/items", common.AuthMiddleware, handler.AddToCart)
+	// Lines of products with variants are picked with ?variant_id=
 	app.Put("/v1/cart/items/:productId", common.AuthMiddleware, handler.UpdateCartItem)
 	app.Delete("/v1/cart/items/:productId", common.AuthMiddleware, handler.RemoveFromCart)
 	app.Delete("/v1/cart", common.AuthMiddleware, handler.ClearCart)
 }
 
 type AddToCartRequest struct {
-	ProductID uint `json:"product_id"`
-	Quantity  int  `json:"quantity"`
+	ProductID uint `json:"product_id" validate:"required"`
+	VariantID uint `json:"variant_id"`
+	Quantity  int  `json:"quantity" validate:"gt=0"`
 }
 
 type UpdateCartItemRequest struct {
-	Quantity int `json:"quantity"`
+	Quantity int `json:"quantity" validate:"gte=0"`
 }
 
 func (h *CartHandler) GetCart(c *fiber.Ctx) error {
@@ -37,9 +39,7 @@ func (h *CartHandler) GetCart(c *fiber.Ctx) error {
 
 	cart, err := h.usecase.GetCart(userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get cart",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(cart)
@@ -49,28 +49,12 @@ func (h *CartHandler) AddToCart(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uint)
 
 	var req AddToCartRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	if req.ProductID == 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Product ID is required",
-		})
-	}
-
-	if req.Quantity <= 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Quantity must be greater than 0",
-		})
-	}
-
-	if err := h.usecase.AddToCart(userID, req.ProductID, req.Quantity); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.usecase.AddToCart(userID, req.ProductID, req.VariantID, req.Quantity); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -83,28 +67,21 @@ func (h *CartHandler) UpdateCartItem(c *fiber.Ctx) error {
 
 	productID, err := strconv.ParseUint(c.Params("productId"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
 	}
 
-	var req UpdateCartItemRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	variantID, err := variantIDQuery(c)
+	if err != nil {
+		return err
 	}
 
-	if req.Quantity < 0 {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Quantity cannot be negative",
-		})
+	var req UpdateCartItemRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	if err := h.usecase.UpdateCartItemQuantity(userID, uint(productID), req.Quantity); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	if err := h.usecase.UpdateCartItemQuantity(userID, uint(productID), variantID, req.Quantity); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -117,15 +94,16 @@ func (h *CartHandler) RemoveFromCart(c *fiber.Ctx) error {
 
 	productID, err := strconv.ParseUint(c.Params("productId"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
 	}
 
-	if err := h.usecase.RemoveFromCart(userID, uint(productID)); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+	variantID, err := variantIDQuery(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.usecase.RemoveFromCart(userID, uint(productID), variantID); err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -137,12 +115,23 @@ func (h *CartHandler) ClearCart(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(uint)
 
 	if err := h.usecase.ClearCart(userID); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to clear cart",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Cart cleared successfully",
 	})
 }
+
+// variantIDQuery reads the optional variant_id query parameter; 0 when absent.
+func variantIDQuery(c *fiber.Ctx) (uint, error) {
+	raw := c.Query("variant_id")
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseUint(raw, 10, 32)
+	if err != nil {
+		return 0, fiber.NewError(fiber.StatusBadRequest, "Invalid variant ID")
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,116 @@
+package http
+
+import (
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CategoryHandler struct {
+	usecase *usecase.CategoryUseCase
+}
+
+func NewCategoryHandler(app *fiber.App, uc *usecase.CategoryUseCase) {
+	handler := &CategoryHandler{usecase: uc}
+
+	// Public routes
+	app.Get("/v1/categories", handler.GetTree)
+	app.Get("/v1/categories/:id", handler.GetByID)
+
+	// Admin routes (require staff or admin role)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Post("/v1/admin/categories", common.AuthMiddleware, staff, handler.Create)
+	app.Put("/v1/admin/categories/:id", common.AuthMiddleware, staff, handler.Update)
+	app.Delete("/v1/admin/categories/:id", common.AuthMiddleware, staff, handler.Delete)
+}
+
+// CategoryRequest is the body of create and update. A missing slug is
+// derived from the name.
+type CategoryRequest struct {
+	ParentID  *uint  `json:"parent_id"`
+	Name      string `json:"name" validate:"required,max=100"`
+	Slug      string `json:"slug" validate:"max=120"`
+	SortOrder int    `json:"sort_order"`
+}
+
+func (r *CategoryRequest) toCategory() *domain.Category {
+	return &domain.Category{
+		ParentID:  r.ParentID,
+		Name:      r.Name,
+		Slug:      r.Slug,
+		SortOrder: r.SortOrder,
+	}
+}
+
+func (h *CategoryHandler) GetTree(c *fiber.Ctx) error {
+	tree, err := h.usecase.GetTree()
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tree)
+}
+
+func (h *CategoryHandler) GetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	category, err := h.usecase.GetCategoryByID(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(category)
+}
+
+func (h *CategoryHandler) Create(c *fiber.Ctx) error {
+	var req CategoryRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	category := req.toCategory()
+	if err := h.usecase.CreateCategory(category); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(category)
+}
+
+func (h *CategoryHandler) Update(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	var req CategoryRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	category := req.toCategory()
+	category.ID = uint(id)
+	if err := h.usecase.UpdateCategory(category); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(category)
+}
+
+func (h *CategoryHandler) Delete(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid category ID")
+	}
+
+	if err := h.usecase.DeleteCategory(uint(id)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,190 @@
+package http
+
+import (
+	"fmt"
+	"io"
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"path"
+	"regexp"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// maxUploadImages caps the files in one upload request.
+const maxUploadImages = 10
+
+// maxUploadBody is the largest upload request accepted: every file at its
+// maximum size plus room for the multipart headers.
+const maxUploadBody = maxUploadImages*usecase.MaxImageSize + 1<<20
+
+var imageUploadPath = regexp.MustCompile(`^/v1/products/[0-9]+/images/?$`)
+
+// IsImageUpload reports whether c is an image upload, the one request
+// allowed past the app-wide body limit; Upload enforces its own.
+func IsImageUpload(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodPost && imageUploadPath.MatchString(c.Path())
+}
+
+type ImageHandler struct {
+	usecase *usecase.ImageUseCase
+}
+
+func NewImageHandler(app *fiber.App, uc *usecase.ImageUseCase) {
+	handler := &ImageHandler{usecase: uc}
+
+	// Public routes
+	app.Get("/v1/products/:id/images", handler.GetByProduct)
+	app.Get("/v1/images/*", handler.Serve)
+
+	// Protected routes (staff or admin only)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Post("/v1/products/:id/images", common.AuthMiddleware, staff, handler.Upload)
+	app.Put("/v1/products/:id/images/order", common.AuthMiddleware, staff, handler.Reorder)
+	app.Delete("/v1/products/:id/images/:imageId", common.AuthMiddleware, staff, handler.Delete)
+}
+
+type ReorderImagesRequest struct {
+	ImageIDs []uint `json:"image_ids" validate:"required,min=1,dive,required"`
+}
+
+func (h *ImageHandler) GetByProduct(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	images, err := h.usecase.GetImages(uint(productID))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(images)
+}
+
+// Upload takes a multipart form with one or more files in the "images"
+// field and appends them to the gallery in the order sent. If one file is
+// rejected, none of them are kept. The body is sized from Content-Length
+// before any of it is read, and files over MaxImageSize are refused before
+// their contents are loaded.
+func (h *ImageHandler) Upload(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	length := c.Request().Header.ContentLength()
+	if length < 0 {
+		return fiber.NewError(fiber.StatusLengthRequired, "Content-Length is required")
+	}
+	if length > maxUploadBody {
+		c.Context().SetConnectionClose()
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, "Request body too large")
+	}
+	// A compressed body could expand far past the size checked above
+	if c.Get(fiber.HeaderContentEncoding) != "" {
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, "Compressed uploads are not supported")
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid multipart form")
+	}
+	files := form.File["images"]
+	if len(files) == 0 {
+		return &common.ValidationError{Fields: []common.FieldError{
+			{Field: "images", Code: "required", Message: "is required"},
+		}}
+	}
+	if len(files) > maxUploadImages {
+		return &common.ValidationError{Fields: []common.FieldError{
+			{Field: "images", Code: "max", Message: fmt.Sprintf("must have at most %d files", maxUploadImages)},
+		}}
+	}
+	for _, fh := range files {
+		if fh.Size > usecase.MaxImageSize {
+			return domain.NewValidationError("image_too_large", fmt.Sprintf("%s: image must be at most %d MB", fh.Filename, usecase.MaxImageSize>>20))
+		}
+	}
+
+	contents := make([][]byte, 0, len(files))
+	for _, fh := range files {
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		data, err := io.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		contents = append(contents, data)
+	}
+
+	uploaded, err := h.usecase.UploadImages(uint(productID), contents)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(uploaded)
+}
+
+func (h *ImageHandler) Reorder(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	var req ReorderImagesRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	images, err := h.usecase.ReorderImages(uint(productID), req.ImageIDs)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(images)
+}
+
+func (h *ImageHandler) Delete(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+	imageID, err := strconv.ParseUint(c.Params("imageId"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid image ID")
+	}
+
+	if err := h.usecase.DeleteImage(uint(productID), uint(imageID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// Serve streams a stored image. Keys contain a hash of the content, so a
+// key never points at different bytes and clients may cache it forever.
+// Missing keys are a 404 whatever the conditional headers say.
+func (h *ImageHandler) Serve(c *fiber.Ctx) error {
+	key := c.Params("*")
+	body, contentType, err := h.usecase.OpenImage(key)
+	if err != nil {
+		return err
+	}
+
+	etag := `"` + path.Base(key) + `"`
+	c.Set(fiber.HeaderCacheControl, "public, max-age=31536000, immutable")
+	c.Set(fiber.HeaderETag, etag)
+	if c.Get(fiber.HeaderIfNoneMatch) == etag {
+		body.Close()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Status(fiber.StatusOK).SendStream(body)
+}
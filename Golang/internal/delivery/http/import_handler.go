@@ -0,0 +1,310 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// productColumns is the CSV header of import and export, in export order.
+var productColumns = []string{"sku", "name", "description", "price", "category", "stock", "image_url", "status"}
+
+type ImportHandler struct {
+	usecase *usecase.ProductUseCase
+}
+
+func NewImportHandler(app *fiber.App, uc *usecase.ProductUseCase) {
+	handler := &ImportHandler{usecase: uc}
+
+	// Admin routes (require staff or admin role)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Post("/v1/admin/products/import", common.AuthMiddleware, staff, handler.Import)
+	app.Get("/v1/admin/products/export", common.AuthMiddleware, staff, handler.Export)
+}
+
+// productRecord is one product as it appears in an import or export file.
+// The category is a slug; a category name works too when importing. An
+// empty status makes new products active and leaves existing ones as they are.
+type productRecord struct {
+	SKU         string  `json:"sku"`
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Price       float64 `json:"price"`
+	Category    string  `json:"category"`
+	Stock       int     `json:"stock"`
+	ImageURL    string  `json:"image_url"`
+	Status      string  `json:"status"`
+}
+
+func (r *productRecord) toProduct() *domain.Product {
+	p := &domain.Product{
+		Name:        strings.TrimSpace(r.Name),
+		Description: r.Description,
+		Price:       r.Price,
+		Category:    r.Category,
+		Stock:       r.Stock,
+		ImageURL:    strings.TrimSpace(r.ImageURL),
+		Status:      domain.ProductStatus(strings.ToLower(strings.TrimSpace(r.Status))),
+	}
+	if sku := strings.TrimSpace(r.SKU); sku != "" {
+		p.SKU = &sku
+	}
+	return p
+}
+
+func recordFromProduct(p *domain.Product) productRecord {
+	r := productRecord{
+		Name:        p.Name,
+		Description: p.Description,
+		Price:       p.Price,
+		Category:    p.Category,
+		Stock:       p.Stock,
+		ImageURL:    p.ImageURL,
+		Status:      string(p.Status),
+	}
+	if p.SKU != nil {
+		r.SKU = *p.SKU
+	}
+	return r
+}
+
+// Import reads products from a CSV file with a header row, or from NDJSON
+// with one object per line, and answers with a per-row report. The format
+// comes from ?format=, or else from the Content-Type.
+func (h *ImportHandler) Import(c *fiber.Ctx) error {
+	format := c.Query("format")
+	if format == "" {
+		switch ct := string(c.Request().Header.ContentType()); {
+		case strings.HasPrefix(ct, "text/csv"):
+			format = formatCSV
+		case strings.HasPrefix(ct, "application/x-ndjson"), strings.HasPrefix(ct, "application/json"):
+			format = formatNDJSON
+		}
+	}
+
+	var rows []domain.ProductImportRow
+	var err error
+	switch format {
+	case formatCSV:
+		rows, err = parseCSVProducts(c.Body())
+	case formatNDJSON:
+		rows, err = parseNDJSONProducts(c.Body())
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "Format must be csv or ndjson")
+	}
+	if err != nil {
+		return err
+	}
+
+	report, err := h.usecase.ImportProducts(rows)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// Export streams every product as CSV or NDJSON, without holding the whole
+// catalog in memory.
+func (h *ImportHandler) Export(c *fiber.Ctx) error {
+	format := c.Query("format", formatCSV)
+	var write func(w *bufio.Writer, products []*domain.Product) error
+	switch format {
+	case formatCSV:
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		write = writeCSVProducts()
+	case formatNDJSON:
+		c.Set(fiber.HeaderContentType, "application/x-ndjson")
+		write = writeNDJSONProducts
+	default:
+		return fiber.NewError(fiber.StatusBadRequest, "Format must be csv or ndjson")
+	}
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="products.`+format+`"`)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		err := h.usecase.ExportProducts(func(products []*domain.Product) error {
+			if err := write(w, products); err != nil {
+				return err
+			}
+			return w.Flush()
+		})
+		// The status line is already sent, so the client only sees a short file
+		if err != nil {
+			log.Printf("Product export failed: %v", err)
+		}
+	})
+	return nil
+}
+
+func parseCSVProducts(body []byte) ([]domain.ProductImportRow, error) {
+	r := csv.NewReader(bytes.NewReader(body))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "CSV header row is missing")
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, required := range []string{"sku", "name", "price"} {
+		if _, ok := columns[required]; !ok {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "CSV header must include sku, name and price")
+		}
+	}
+
+	var rows []domain.ProductImportRow
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			rows = append(rows, domain.ProductImportRow{Line: perr.StartLine - 1, Errors: []domain.ImportError{
+				{Code: "invalid_row", Message: perr.Err.Error()},
+			}})
+			continue
+		}
+		if err != nil {
+			return nil, fiber.NewError(fiber.StatusBadRequest, "CSV could not be read: "+err.Error())
+		}
+		line, _ := r.FieldPos(0)
+		line-- // the header is not a data row
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row := domain.ProductImportRow{Line: line}
+		rec := productRecord{
+			SKU:         field("sku"),
+			Name:        field("name"),
+			Description: field("description"),
+			Category:    field("category"),
+			ImageURL:    field("image_url"),
+			Status:      field("status"),
+		}
+		if rec.Price, err = strconv.ParseFloat(field("price"), 64); err != nil {
+			row.Errors = append(row.Errors, domain.ImportError{Field: "price", Code: "invalid_number", Message: "must be a number"})
+		}
+		if s := field("stock"); s != "" {
+			if rec.Stock, err = strconv.Atoi(s); err != nil {
+				row.Errors = append(row.Errors, domain.ImportError{Field: "stock", Code: "invalid_number", Message: "must be a whole number"})
+			}
+		}
+		rows = append(rows, checkProductRecord(row, &rec))
+	}
+	return rows, nil
+}
+
+func parseNDJSONProducts(body []byte) ([]domain.ProductImportRow, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	var rows []domain.ProductImportRow
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := domain.ProductImportRow{Line: line}
+		var rec productRecord
+		if err := json.Unmarshal(text, &rec); err != nil {
+			row.Errors = []domain.ImportError{{Code: "invalid_json", Message: "line is not a valid product object"}}
+			rows = append(rows, row)
+			continue
+		}
+		rows = append(rows, checkProductRecord(row, &rec))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fiber.NewError(fiber.StatusBadRequest, "NDJSON could not be read: "+err.Error())
+	}
+	return rows, nil
+}
+
+// checkProductRecord runs the same struct validation as POST /v1/products
+// and attaches the product, or the field errors, to the row.
+func checkProductRecord(row domain.ProductImportRow, rec *productRecord) domain.ProductImportRow {
+	p := rec.toProduct()
+	if err := common.Validate(p); err != nil {
+		var verr *common.ValidationError
+		if !errors.As(err, &verr) {
+			row.Errors = append(row.Errors, domain.ImportError{Code: "invalid_row", Message: err.Error()})
+		} else {
+			for _, f := range verr.Fields {
+				row.Errors = append(row.Errors, domain.ImportError{Field: f.Field, Code: f.Code, Message: f.Message})
+			}
+		}
+	}
+	if len(row.Errors) > 0 {
+		for i := range row.Errors {
+			row.Errors[i].SKU = rec.SKU
+		}
+		return row
+	}
+	row.Product = p
+	return row
+}
+
+// writeCSVProducts returns a writer that emits the header before the first batch.
+func writeCSVProducts() func(w *bufio.Writer, products []*domain.Product) error {
+	headerWritten := false
+	return func(w *bufio.Writer, products []*domain.Product) error {
+		cw := csv.NewWriter(w)
+		if !headerWritten {
+			if err := cw.Write(productColumns); err != nil {
+				return err
+			}
+			headerWritten = true
+		}
+		for _, p := range products {
+			r := recordFromProduct(p)
+			err := cw.Write([]string{
+				r.SKU,
+				r.Name,
+				r.Description,
+				strconv.FormatFloat(r.Price, 'f', -1, 64),
+				r.Category,
+				strconv.Itoa(r.Stock),
+				r.ImageURL,
+				r.Status,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+}
+
+func writeNDJSONProducts(w *bufio.Writer, products []*domain.Product) error {
+	enc := json.NewEncoder(w)
+	for _, p := range products {
+		if err := enc.Encode(recordFromProduct(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
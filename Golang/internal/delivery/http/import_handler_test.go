@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/json"
+	"io"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"my-go-project/pkg/token"
+	nethttp "net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// exportProductRepo serves a fixed product list; the embedded interface
+// makes any other method panic.
+type exportProductRepo struct {
+	domain.ProductRepository
+	products []*domain.Product
+	upserted []*domain.Product
+}
+
+func (r *exportProductRepo) UpsertBySKU(products []*domain.Product) (int, int, []string, error) {
+	r.upserted = append(r.upserted, products...)
+	return len(products), 0, nil, nil
+}
+
+func (r *exportProductRepo) ForEachBatch(size int, fn func([]*domain.Product) error) error {
+	return fn(r.products)
+}
+
+type exportCategoryRepo struct {
+	domain.CategoryRepository
+}
+
+func (exportCategoryRepo) GetAll() ([]*domain.Category, error) {
+	return []*domain.Category{}, nil
+}
+
+// newExportTestApp registers the product routes before the import routes,
+// in the order main does.
+func newExportTestApp(t *testing.T, products ...*domain.Product) (*fiber.App, string) {
+	app, accessToken, _ := newImportTestApp(t, products...)
+	return app, accessToken
+}
+
+func newImportTestApp(t *testing.T, products ...*domain.Product) (*fiber.App, string, *exportProductRepo) {
+	t.Helper()
+	token.InitKeys()
+	accessToken, _, err := token.GenerateToken(1, domain.RoleStaff, token.TypeAccess, token.AccessTokenTTL)
+	if err != nil {
+		t.Fatalf("generate token: %v", err)
+	}
+
+	repo := &exportProductRepo{products: products}
+	uc := usecase.NewProductUseCase(repo, exportCategoryRepo{}, nil)
+	app := fiber.New()
+	NewProductHandler(app, uc)
+	NewImportHandler(app, uc)
+	return app, accessToken, repo
+}
+
+func TestExportRouteIsNotShadowedByProductID(t *testing.T) {
+	sku := "MUG-1"
+	app, accessToken := newExportTestApp(t, &domain.Product{ID: 1, SKU: &sku, Name: "Mug", Price: 9.5, Stock: 3, Status: domain.ProductStatusActive})
+
+	req := httptest.NewRequest(nethttp.MethodGet, "/v1/admin/products/export", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != fiber.StatusOK {
+		t.Fatalf("status %d, want 200: %s", resp.StatusCode, body)
+	}
+	if ct := resp.Header.Get(fiber.HeaderContentType); !strings.HasPrefix(ct, "text/csv") {
+		t.Errorf("content type %q, want text/csv", ct)
+	}
+	if !strings.Contains(string(body), "MUG-1") {
+		t.Errorf("export does not list the product:\n%s", body)
+	}
+}
+
+func TestExportIncludesStatus(t *testing.T) {
+	sku := "MUG-1"
+	app, accessToken := newExportTestApp(t, &domain.Product{ID: 1, SKU: &sku, Name: "Mug", Price: 9.5, Stock: 3, Status: domain.ProductStatusDraft})
+
+	req := httptest.NewRequest(nethttp.MethodGet, "/v1/admin/products/export", nil)
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	want := "sku,name,description,price,category,stock,image_url,status\nMUG-1,Mug,,9.5,,3,,draft\n"
+	if string(body) != want {
+		t.Errorf("export:\n%s\nwant:\n%s", body, want)
+	}
+}
+
+func TestImportReadsStatus(t *testing.T) {
+	app, accessToken, repo := newImportTestApp(t)
+
+	csv := "sku,name,price,status\n" +
+		"A-1,Draft item,5,draft\n" +
+		"A-2,Live item,5,Active\n" +
+		"A-3,No status,5,\n" +
+		"A-4,Archived item,5,archived\n" +
+		"A-5,Odd status,5,hidden\n"
+	req := httptest.NewRequest(nethttp.MethodPost, "/v1/admin/products/import", strings.NewReader(csv))
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set(fiber.HeaderContentType, "text/csv")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	var report domain.ImportReport
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+
+	statuses := map[string]domain.ProductStatus{}
+	for _, p := range repo.upserted {
+		statuses[*p.SKU] = p.Status
+	}
+	want := map[string]domain.ProductStatus{
+		"A-1": domain.ProductStatusDraft,
+		"A-2": domain.ProductStatusActive,
+		"A-3": "", // kept for existing products, active for new ones
+	}
+	if len(statuses) != len(want) {
+		t.Errorf("upserted %v, want %v", statuses, want)
+	}
+	for sku, status := range want {
+		if got, ok := statuses[sku]; !ok || got != status {
+			t.Errorf("%s upserted with status %q, want %q", sku, got, status)
+		}
+	}
+
+	failed := map[string]string{}
+	for _, e := range report.Errors {
+		failed[e.SKU] = e.Field
+	}
+	if report.Failed != 2 || failed["A-4"] != "status" || failed["A-5"] != "status" {
+		t.Errorf("report %+v, want A-4 and A-5 refused on status", report)
+	}
+}
@@ -0,0 +1,14 @@
+package http
+
+import (
+	"my-go-project/pkg/token"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func NewJWKSHandler(app *fiber.App) {
+	app.Get("/.well-known/jwks.json", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderCacheControl, "public, max-age=300")
+		return c.Status(fiber.StatusOK).JSON(token.JWKS())
+	})
+}
@@ -20,92 +20,111 @@ func NewOrderHandler(app *fiber.App, uc *usecase.OrderUseCase) {
 	app.Post("/v1/orders", common.AuthMiddleware, handler.CreateOrder)
 	app.Get("/v1/orders", common.AuthMiddleware, handler.GetUserOrders)
 	app.Get("/v1/orders/:id", common.AuthMiddleware, handler.GetOrderByID)
+	app.Get("/v1/orders/:id/history", common.AuthMiddleware, handler.GetOrderHistory)
+	app.Post("/v1/orders/:id/cancel", common.AuthMiddleware, handler.CancelOrder)
 
-	// Admin routes (require authentication)
-	app.Get("/v1/admin/orders", common.AuthMiddleware, handler.GetAllOrders)
-	app.Put("/v1/admin/orders/:id/status", common.AuthMiddleware, handler.UpdateOrderStatus)
+	// Admin routes (require staff or admin role)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Get("/v1/admin/orders", common.AuthMiddleware, staff, handler.GetAllOrders)
+	app.Put("/v1/admin/orders/:id/status", common.AuthMiddleware, staff, handler.UpdateOrderStatus)
 }
 
 type CreateOrderRequest struct {
-	ShippingAddress string `json:"shipping_address"`
+	ShippingAddress string `json:"shipping_address" validate:"required,max=500"`
+}
+
+type CancelOrderRequest struct {
+	Reason string `json:"reason" validate:"required,max=500"`
 }
 
 type UpdateOrderStatusRequest struct {
-	Status string `json:"status"`
+	Status string `json:"status" validate:"required,oneof=pending confirmed shipped delivered cancelled"`
+	Reason string `json:"reason" validate:"max=500"`
 }
 
 func (h *OrderHandler) CreateOrder(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(uint)
+	userID := c.Locals("user_id").(uint)
 
 	var req CreateOrderRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	if req.ShippingAddress == "" {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Shipping address is required",
-		})
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	order, err := h.usecase.CreateOrder(userID, req.ShippingAddress)
+	order, err := h.usecase.CreateOrder(c.UserContext(), userID, req.ShippingAddress)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": err.Error(),
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(order)
 }
 
 func (h *OrderHandler) GetUserOrders(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(uint)
+	userID := c.Locals("user_id").(uint)
 
 	orders, err := h.usecase.GetOrdersByUserID(userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve orders",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(orders)
 }
 
 func (h *OrderHandler) GetOrderByID(c *fiber.Ctx) error {
-	userID := c.Locals("userID").(uint)
+	userID := c.Locals("user_id").(uint)
 
 	orderID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid order ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid order ID")
 	}
 
-	order, err := h.usecase.GetOrderByID(uint(orderID))
+	// Only the owner of the order can see it
+	order, err := h.usecase.GetUserOrder(userID, uint(orderID))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Order not found",
-		})
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(order)
+}
+
+// CancelOrder is for the owner of the order; staff cancel through the status route.
+func (h *OrderHandler) CancelOrder(c *fiber.Ctx) error {
+	orderID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid order ID")
+	}
+
+	var req CancelOrderRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	// Check if the order belongs to the authenticated user
-	if order.UserID != userID {
-		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-			"error": "Access denied",
-		})
+	order, err := h.usecase.CancelOrder(c.UserContext(), common.CurrentActor(c), uint(orderID), req.Reason)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(order)
 }
 
+// GetOrderHistory is open to the owner of the order and to staff.
+func (h *OrderHandler) GetOrderHistory(c *fiber.Ctx) error {
+	orderID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid order ID")
+	}
+
+	history, err := h.usecase.GetOrderHistory(common.CurrentActor(c), uint(orderID))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(history)
+}
+
 func (h *OrderHandler) GetAllOrders(c *fiber.Ctx) error {
 	orders, err := h.usecase.GetAllOrders()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve orders",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(orders)
@@ -114,41 +133,17 @@ func (h *OrderHandler) GetAllOrders(c *fiber.Ctx) error {
 func (h *OrderHandler) UpdateOrderStatus(c *fiber.Ctx) error {
 	orderID, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid order ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid order ID")
 	}
 
 	var req UpdateOrderStatusRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
-	// Convert string status to domain.OrderStatus
-	var status domain.OrderStatus
-	switch req.Status {
-	case "pending":
-		status = domain.OrderStatusPending
-	case "confirmed":
-		status = domain.OrderStatusConfirmed
-	case "shipped":
-		status = domain.OrderStatusShipped
-	case "delivered":
-		status = domain.OrderStatusDelivered
-	case "cancelled":
-		status = domain.OrderStatusCancelled
-	default:
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid order status",
-		})
-	}
-
-	if err := h.usecase.UpdateOrderStatus(uint(orderID), status); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update order status",
-		})
+	_, err = h.usecase.TransitionOrder(c.UserContext(), uint(orderID), domain.OrderStatus(req.Status), common.CurrentActor(c), req.Reason)
+	if err != nil {
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -0,0 +1,81 @@
+package http
+
+import (
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PriceHandler struct {
+	usecase *usecase.PriceUseCase
+}
+
+func NewPriceHandler(app *fiber.App, uc *usecase.PriceUseCase) {
+	handler := &PriceHandler{usecase: uc}
+
+	// Admin routes (require staff or admin role)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Get("/v1/admin/products/:id/prices", common.AuthMiddleware, staff, handler.Timeline)
+	app.Post("/v1/admin/products/:id/prices", common.AuthMiddleware, staff, handler.Schedule)
+	app.Delete("/v1/admin/products/:id/prices/:priceId", common.AuthMiddleware, staff, handler.Cancel)
+}
+
+// SchedulePriceRequest plans a price change; effective_at is RFC 3339.
+type SchedulePriceRequest struct {
+	Price       float64   `json:"price" validate:"gt=0"`
+	EffectiveAt time.Time `json:"effective_at" validate:"required"`
+}
+
+func (h *PriceHandler) Timeline(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	prices, err := h.usecase.GetPriceTimeline(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(prices)
+}
+
+func (h *PriceHandler) Schedule(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	var req SchedulePriceRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	price, err := h.usecase.SchedulePrice(uint(id), req.Price, req.EffectiveAt)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(price)
+}
+
+func (h *PriceHandler) Cancel(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+	priceID, err := strconv.ParseUint(c.Params("priceId"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid price ID")
+	}
+
+	if err := h.usecase.CancelScheduledPrice(uint(id), uint(priceID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
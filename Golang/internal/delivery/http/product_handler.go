@@ -5,6 +5,7 @@ import (
 	"my-go-project/internal/domain"
 	"my-go-project/internal/usecase"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -17,29 +18,175 @@ func NewProductHandler(app *fiber.App, uc *usecase.ProductUseCase) {
 	handler := &ProductHandler{usecase: uc}
 
 	// Public routes
-	app.Get("/v1/products", handler.GetAll)
+	app.Get("/v1/products", handler.List)
+	// Registered before /:id, which would otherwise match them
+	app.Get("/v1/products/search", handler.Search)
+	app.Get("/v1/products/suggest", handler.Suggest)
 	app.Get("/v1/products/:id", handler.GetByID)
+	// Kept for older clients; both are a preset of the routes above
 	app.Get("/v1/products/category/:category", handler.GetByCategory)
 	app.Get("/v1/products/search/:name", handler.SearchByName)
 
-	// Protected routes (admin only)
-	app.Post("/v1/products", common.AuthMiddleware, handler.Create)
-	app.Put("/v1/products/:id", common.AuthMiddleware, handler.Update)
-	app.Delete("/v1/products/:id", common.AuthMiddleware, handler.Delete)
+	// Protected routes (staff or admin only)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Post("/v1/products", common.AuthMiddleware, staff, handler.Create)
+	// Both need an If-Match header with the ETag of the version being edited.
+	// PUT replaces every editable field, PATCH only the fields sent.
+	app.Put("/v1/products/:id", common.AuthMiddleware, staff, handler.Update)
+	app.Patch("/v1/products/:id", common.AuthMiddleware, staff, handler.Patch)
+	app.Delete("/v1/products/:id", common.AuthMiddleware, staff, handler.Delete)
+	// Unlike the public routes these see drafts and archived products
+	app.Get("/v1/admin/products", common.AuthMiddleware, staff, handler.AdminList)
+	// Numeric only, so the import and export routes registered later still match
+	app.Get("/v1/admin/products/:id<int>", common.AuthMiddleware, staff, handler.AdminGetByID)
+
+	// Admin routes
+	admin := common.RequireRole(domain.RoleAdmin)
+	app.Post("/v1/admin/products/:id/restore", common.AuthMiddleware, admin, handler.Restore)
+}
+
+// ListProductsRequest is the query of product listings. Status is only
+// honoured by the admin listing; the public one always shows active products.
+// Attribute filters are read separately, see attributeFilters.
+type ListProductsRequest struct {
+	Status   string   `query:"status" json:"status" validate:"omitempty,oneof=draft active archived"`
+	Category string   `query:"category" json:"category" validate:"max=100"`
+	Name     string   `query:"name" json:"name" validate:"max=200"`
+	MinPrice *float64 `query:"min_price" json:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice *float64 `query:"max_price" json:"max_price" validate:"omitempty,gte=0"`
+	InStock  bool     `query:"in_stock" json:"in_stock"`
+	Sort     string   `query:"sort" json:"sort" validate:"omitempty,oneof=id price name created_at"`
+	Order    string   `query:"order" json:"order" validate:"omitempty,oneof=asc desc"`
+	Limit    int      `query:"limit" json:"limit" validate:"gte=0,max=100"`
+	Offset   int      `query:"offset" json:"offset" validate:"gte=0"`
+	Cursor   string   `query:"cursor" json:"cursor"`
+}
+
+func (r *ListProductsRequest) toFilter() domain.ProductFilter {
+	return domain.ProductFilter{
+		Status:   domain.ProductStatus(r.Status),
+		Category: r.Category,
+		Name:     r.Name,
+		MinPrice: r.MinPrice,
+		MaxPrice: r.MaxPrice,
+		InStock:  r.InStock,
+		Sort:     domain.ProductSort(r.Sort),
+		Desc:     r.Order == "desc",
+		Limit:    r.Limit,
+		Offset:   r.Offset,
+		Cursor:   r.Cursor,
+	}
+}
+
+type SearchProductsRequest struct {
+	Q        string   `query:"q" json:"q" validate:"required,max=200"`
+	Category string   `query:"category" json:"category" validate:"max=100"`
+	MinPrice *float64 `query:"min_price" json:"min_price" validate:"omitempty,gte=0"`
+	MaxPrice *float64 `query:"max_price" json:"max_price" validate:"omitempty,gte=0"`
+	InStock  bool     `query:"in_stock" json:"in_stock"`
+	Limit    int      `query:"limit" json:"limit" validate:"gte=0,max=100"`
+	Offset   int      `query:"offset" json:"offset" validate:"gte=0"`
+}
+
+func (r *SearchProductsRequest) toFilter() domain.ProductFilter {
+	return domain.ProductFilter{
+		Status:   domain.ProductStatusActive,
+		Category: r.Category,
+		MinPrice: r.MinPrice,
+		MaxPrice: r.MaxPrice,
+		InStock:  r.InStock,
+		Limit:    r.Limit,
+		Offset:   r.Offset,
+	}
+}
+
+// UpdateProductRequest is the body of PUT, which replaces the product's
+// editable fields as a whole: optional fields left out are cleared rather
+// than kept. The ID, version and timestamps never come from the body.
+type UpdateProductRequest struct {
+	SKU         string  `json:"sku" validate:"max=64"`
+	Name        string  `json:"name" validate:"required,max=200"`
+	Description string  `json:"description" validate:"max=5000"`
+	Price       float64 `json:"price" validate:"gt=0"`
+	ImageURL    string  `json:"image_url" validate:"omitempty,url"`
+	CategoryID  *uint   `json:"category_id"`
+	Category    string  `json:"category" validate:"max=100"`
+	Stock       *int    `json:"stock" validate:"required,gte=0"`
+	Status      string  `json:"status" validate:"required,oneof=draft active"`
+}
+
+func (r *UpdateProductRequest) applyTo(p *domain.Product) {
+	sku := r.SKU
+	p.SKU = &sku // an empty SKU is cleared by the usecase
+	p.Name = r.Name
+	p.Description = r.Description
+	p.Price = r.Price
+	p.ImageURL = r.ImageURL
+	p.CategoryID = r.CategoryID
+	p.Category = r.Category
+	p.Stock = *r.Stock
+	p.Status = domain.ProductStatus(r.Status)
+}
+
+// PatchProductRequest holds the fields PATCH may change. Fields left out,
+// or sent as null, keep their stored value.
+type PatchProductRequest struct {
+	SKU         *string  `json:"sku" validate:"omitempty,max=64"`
+	Name        *string  `json:"name" validate:"omitempty,min=1,max=200"`
+	Description *string  `json:"description" validate:"omitempty,max=5000"`
+	Price       *float64 `json:"price" validate:"omitempty,gt=0"`
+	ImageURL    *string  `json:"image_url" validate:"omitempty,url"`
+	CategoryID  *uint    `json:"category_id"`
+	Category    *string  `json:"category" validate:"omitempty,max=100"`
+	Stock       *int     `json:"stock" validate:"omitempty,gte=0"`
+	Status      *string  `json:"status" validate:"omitempty,oneof=draft active"`
+}
+
+func (r *PatchProductRequest) applyTo(p *domain.Product) {
+	if r.SKU != nil {
+		p.SKU = r.SKU
+	}
+	if r.Name != nil {
+		p.Name = *r.Name
+	}
+	if r.Description != nil {
+		p.Description = *r.Description
+	}
+	if r.Price != nil {
+		p.Price = *r.Price
+	}
+	if r.ImageURL != nil {
+		p.ImageURL = *r.ImageURL
+	}
+	// A new category name replaces the category, unless an id is sent too
+	if r.Category != nil {
+		p.Category = *r.Category
+		p.CategoryID = nil
+	}
+	if r.CategoryID != nil {
+		p.CategoryID = r.CategoryID
+	}
+	if r.Stock != nil {
+		p.Stock = *r.Stock
+	}
+	if r.Status != nil {
+		p.Status = domain.ProductStatus(*r.Status)
+	}
+}
+
+type SuggestProductsRequest struct {
+	Q     string `query:"q" json:"q" validate:"required,max=100"`
+	Limit int    `query:"limit" json:"limit" validate:"gte=0,max=20"`
 }
 
 func (h *ProductHandler) Create(c *fiber.Ctx) error {
 	var product domain.Product
-	if err := c.BodyParser(&product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	if err := common.BindAndValidate(c, &product); err != nil {
+		return err
 	}
 
 	if err := h.usecase.CreateProduct(&product); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create product",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(product)
@@ -48,99 +195,278 @@ func (h *ProductHandler) Create(c *fiber.Ctx) error {
 func (h *ProductHandler) GetByID(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
 	}
 
-	product, err := h.usecase.GetProductByID(uint(id))
+	product, err := h.usecase.GetActiveProduct(uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product not found",
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(product)
+	return respondProduct(c, product)
 }
 
-func (h *ProductHandler) GetAll(c *fiber.Ctx) error {
-	products, err := h.usecase.GetAllProducts()
+func (h *ProductHandler) AdminGetByID(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve products",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	product, err := h.usecase.GetProductByID(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return respondProduct(c, product)
+}
+
+func (h *ProductHandler) List(c *fiber.Ctx) error {
+	var req ListProductsRequest
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
 	}
+	filter := req.toFilter()
+	filter.Status = domain.ProductStatusActive
+	return h.list(c, filter)
+}
 
-	return c.Status(fiber.StatusOK).JSON(products)
+func (h *ProductHandler) AdminList(c *fiber.Ctx) error {
+	var req ListProductsRequest
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
+	}
+	return h.list(c, req.toFilter())
 }
 
 func (h *ProductHandler) Update(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
+	}
+
+	var req UpdateProductRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
 	product, err := h.usecase.GetProductByID(uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product not found",
-		})
+		return err
+	}
+	req.applyTo(product)
+	product.Version = version
+
+	if err := h.usecase.UpdateProduct(product); err != nil {
+		return err
+	}
+
+	return respondProduct(c, product)
+}
+
+func (h *ProductHandler) Patch(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	version, err := ifMatchVersion(c)
+	if err != nil {
+		return err
 	}
 
-	if err := c.BodyParser(product); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+	var req PatchProductRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
 	}
 
+	product, err := h.usecase.GetProductByID(uint(id))
+	if err != nil {
+		return err
+	}
+	req.applyTo(product)
+	product.Version = version
+
 	if err := h.usecase.UpdateProduct(product); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update product",
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(product)
+	return respondProduct(c, product)
 }
 
 func (h *ProductHandler) Delete(c *fiber.Ctx) error {
 	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid product ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
 	}
 
-	if err := h.usecase.DeleteProduct(uint(id)); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Product not found",
-		})
+	if err := h.usecase.ArchiveProduct(uint(id)); err != nil {
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
 }
 
-func (h *ProductHandler) GetByCategory(c *fiber.Ctx) error {
-	category := c.Params("category")
-	products, err := h.usecase.GetProductsByCategory(category)
+func (h *ProductHandler) Restore(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve products",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	product, err := h.usecase.RestoreProduct(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return respondProduct(c, product)
+}
+
+// productETag is the strong ETag of a product version.
+func productETag(p *domain.Product) string {
+	return `"` + strconv.Itoa(p.Version) + `"`
+}
+
+// respondProduct sends the product with its ETag, or 304 when the client
+// already has this version.
+func respondProduct(c *fiber.Ctx, p *domain.Product) error {
+	etag := productETag(p)
+	c.Set(fiber.HeaderETag, etag)
+	if c.Method() == fiber.MethodGet && c.Get(fiber.HeaderIfNoneMatch) == etag {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return c.Status(fiber.StatusOK).JSON(p)
+}
+
+// ifMatchVersion reads the product version a write is based on from the
+// If-Match header. Writes without one are refused with 428, so a client
+// cannot overwrite changes it has never seen.
+func ifMatchVersion(c *fiber.Ctx) (int, error) {
+	header := strings.TrimSpace(c.Get(fiber.HeaderIfMatch))
+	if header == "" {
+		return 0, fiber.NewError(fiber.StatusPreconditionRequired, "If-Match header with the product ETag is required")
+	}
+	// Weak ETags never match under If-Match
+	tag, ok := strings.CutPrefix(header, `"`)
+	if ok {
+		tag, ok = strings.CutSuffix(tag, `"`)
 	}
+	version, err := strconv.Atoi(tag)
+	if !ok || err != nil || version <= 0 {
+		return 0, domain.NewPreconditionFailedError("product_modified", "If-Match does not match the current product version")
+	}
+	return version, nil
+}
 
-	return c.Status(fiber.StatusOK).JSON(products)
+func (h *ProductHandler) GetByCategory(c *fiber.Ctx) error {
+	var req ListProductsRequest
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
+	}
+	filter := req.toFilter()
+	filter.Status = domain.ProductStatusActive
+	filter.Category = c.Params("category")
+	return h.list(c, filter)
+}
+
+func (h *ProductHandler) Search(c *fiber.Ctx) error {
+	var req SearchProductsRequest
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
+	}
+	return h.search(c, req.Q, req.toFilter())
 }
 
 func (h *ProductHandler) SearchByName(c *fiber.Ctx) error {
-	name := c.Params("name")
-	products, err := h.usecase.SearchProductsByName(name)
+	var req SearchProductsRequest
+	req.Q = c.Params("name")
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
+	}
+	return h.search(c, req.Q, req.toFilter())
+}
+
+func (h *ProductHandler) Suggest(c *fiber.Ctx) error {
+	var req SuggestProductsRequest
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	suggestions, err := h.usecase.SuggestProducts(req.Q, req.Limit)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"suggestions": suggestions,
+	})
+}
+
+func (h *ProductHandler) search(c *fiber.Ctx, query string, filter domain.ProductFilter) error {
+	page, err := h.usecase.SearchProducts(query, filter)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to search products",
-		})
+		return err
 	}
 
-	return c.Status(fiber.StatusOK).JSON(products)
+	return c.Status(fiber.StatusOK).JSON(common.PaginatedResponse{
+		Data:   page.Hits,
+		Total:  page.Total,
+		Limit:  page.Limit,
+		Offset: filter.Offset,
+	})
+}
+
+// productListResponse is a page of products with the facets of the
+// listed category's attributes.
+type productListResponse struct {
+	common.PaginatedResponse
+	Facets []*domain.AttributeFacet `json:"facets,omitempty"`
+}
+
+func (h *ProductHandler) list(c *fiber.Ctx, filter domain.ProductFilter) error {
+	filter.Attributes = attributeFilters(c)
+	page, err := h.usecase.ListProducts(filter)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(productListResponse{
+		PaginatedResponse: common.PaginatedResponse{
+			Data:       page.Products,
+			Total:      page.Total,
+			Limit:      page.Limit,
+			Offset:     filter.Offset,
+			NextCursor: page.NextCursor,
+		},
+		Facets: page.Facets,
+	})
+}
+
+// attributeFilters reads attribute filters from query keys of the form
+// attr.<code>, e.g. ?attr.ram_gb=8..16&attr.color=black,white. Commas
+// separate alternatives; a repeated key adds more.
+func attributeFilters(c *fiber.Ctx) []domain.AttributeFilter {
+	var filters []domain.AttributeFilter
+	index := map[string]int{}
+	c.Context().QueryArgs().VisitAll(func(key, value []byte) {
+		code, ok := strings.CutPrefix(string(key), "attr.")
+		if !ok || code == "" {
+			return
+		}
+		i, seen := index[code]
+		if !seen {
+			i = len(filters)
+			index[code] = i
+			filters = append(filters, domain.AttributeFilter{Code: code})
+		}
+		for _, v := range strings.Split(string(value), ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				filters[i].Values = append(filters[i].Values, v)
+			}
+		}
+	})
+	return filters
 }
@@ -0,0 +1,186 @@
+package http
+
+import (
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ReturnHandler struct {
+	usecase *usecase.ReturnUseCase
+}
+
+func NewReturnHandler(app *fiber.App, uc *usecase.ReturnUseCase) {
+	handler := &ReturnHandler{usecase: uc}
+
+	// User routes (require authentication)
+	app.Post("/v1/orders/:id/returns", common.AuthMiddleware, handler.RequestReturn)
+	app.Get("/v1/returns", common.AuthMiddleware, handler.GetUserReturns)
+	app.Get("/v1/returns/:id", common.AuthMiddleware, handler.GetUserReturn)
+
+	// Admin routes (require staff or admin role)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Get("/v1/admin/returns", common.AuthMiddleware, staff, handler.GetAllReturns)
+	app.Get("/v1/admin/returns/:id", common.AuthMiddleware, staff, handler.GetReturn)
+	app.Post("/v1/admin/returns/:id/approve", common.AuthMiddleware, staff, handler.Approve)
+	app.Post("/v1/admin/returns/:id/reject", common.AuthMiddleware, staff, handler.Reject)
+	app.Post("/v1/admin/returns/:id/receive", common.AuthMiddleware, staff, handler.Receive)
+	app.Post("/v1/admin/returns/:id/refund", common.AuthMiddleware, staff, handler.Refund)
+}
+
+type ReturnItemRequest struct {
+	OrderItemID uint   `json:"order_item_id" validate:"required"`
+	Quantity    int    `json:"quantity" validate:"required,gt=0"`
+	Reason      string `json:"reason" validate:"max=500"`
+}
+
+type CreateReturnRequest struct {
+	Reason string              `json:"reason" validate:"required,max=500"`
+	Items  []ReturnItemRequest `json:"items" validate:"required,min=1,max=100,dive"`
+}
+
+type ListReturnsRequest struct {
+	Status string `query:"status" validate:"omitempty,oneof=requested approved rejected received refunded"`
+}
+
+// ProcessReturnRequest is the body of the admin steps; restock only applies
+// when receiving.
+type ProcessReturnRequest struct {
+	Note    string `json:"note" validate:"max=500"`
+	Restock bool   `json:"restock"`
+}
+
+func (h *ReturnHandler) RequestReturn(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	orderID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid order ID")
+	}
+
+	var req CreateReturnRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+	items := make([]domain.ReturnItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		items = append(items, domain.ReturnItem{
+			OrderItemID: item.OrderItemID,
+			Quantity:    item.Quantity,
+			Reason:      item.Reason,
+		})
+	}
+
+	ret, err := h.usecase.RequestReturn(c.UserContext(), userID, uint(orderID), req.Reason, items)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(ret)
+}
+
+func (h *ReturnHandler) GetUserReturns(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	returns, err := h.usecase.GetUserReturns(userID)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(returns)
+}
+
+func (h *ReturnHandler) GetUserReturn(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(uint)
+
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid return ID")
+	}
+
+	// Only the user who asked for the return can see it
+	ret, err := h.usecase.GetUserReturn(userID, uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ret)
+}
+
+func (h *ReturnHandler) GetAllReturns(c *fiber.Ctx) error {
+	var req ListReturnsRequest
+	if err := common.BindQueryAndValidate(c, &req); err != nil {
+		return err
+	}
+
+	returns, err := h.usecase.GetAllReturns(domain.ReturnStatus(req.Status))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(returns)
+}
+
+func (h *ReturnHandler) GetReturn(c *fiber.Ctx) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid return ID")
+	}
+
+	ret, err := h.usecase.GetReturn(uint(id))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ret)
+}
+
+func (h *ReturnHandler) Approve(c *fiber.Ctx) error {
+	return h.process(c, func(id uint, req ProcessReturnRequest) (*domain.ReturnRequest, error) {
+		return h.usecase.ApproveReturn(c.UserContext(), id, req.Note)
+	})
+}
+
+func (h *ReturnHandler) Reject(c *fiber.Ctx) error {
+	return h.process(c, func(id uint, req ProcessReturnRequest) (*domain.ReturnRequest, error) {
+		return h.usecase.RejectReturn(c.UserContext(), id, req.Note)
+	})
+}
+
+func (h *ReturnHandler) Receive(c *fiber.Ctx) error {
+	return h.process(c, func(id uint, req ProcessReturnRequest) (*domain.ReturnRequest, error) {
+		return h.usecase.ReceiveReturn(c.UserContext(), id, req.Restock, req.Note)
+	})
+}
+
+func (h *ReturnHandler) Refund(c *fiber.Ctx) error {
+	return h.process(c, func(id uint, req ProcessReturnRequest) (*domain.ReturnRequest, error) {
+		return h.usecase.RefundReturn(c.UserContext(), id, req.Note)
+	})
+}
+
+// process parses what every admin step takes, and an empty body as no
+// note, then runs step.
+func (h *ReturnHandler) process(c *fiber.Ctx, step func(id uint, req ProcessReturnRequest) (*domain.ReturnRequest, error)) error {
+	id, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid return ID")
+	}
+
+	var req ProcessReturnRequest
+	if len(c.Body()) > 0 {
+		if err := common.BindAndValidate(c, &req); err != nil {
+			return err
+		}
+	}
+
+	ret, err := step(uint(id), req)
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(ret)
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"my-go-project/internal/common"
 	"my-go-project/internal/domain"
 	"my-go-project/internal/usecase"
 	"strconv"
@@ -18,10 +19,21 @@ func NewReviewHandler(app *fiber.App, uc *usecase.ReviewUsecase) {
 	app.Get("/v1/reviews/:productID", handler.GetReviewsByProductID)
 }
 
+type CreateReviewRequest struct {
+	ProductID uint   `json:"product_id" validate:"required"`
+	Rating    int    `json:"rating" validate:"min=1,max=5"`
+	Comment   string `json:"comment" validate:"max=2000"`
+}
+
 func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
-	var review domain.Review
-	if err := c.BodyParser(&review); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	var req CreateReviewRequest
+	if err := common.BindAndValidate(c, &req); err != nil {
+		return err
+	}
+	review := domain.Review{
+		ProductID: req.ProductID,
+		Rating:    req.Rating,
+		Comment:   req.Comment,
 	}
 	// Lấy userID từ context nếu có xác thực JWT
 	if userID := c.Locals("user_id"); userID != nil {
@@ -30,7 +42,7 @@ func (h *ReviewHandler) CreateReview(c *fiber.Ctx) error {
 		}
 	}
 	if err := h.reviewUsecase.CreateReview(&review); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.Status(fiber.StatusCreated).JSON(review)
 }
@@ -39,11 +51,11 @@ func (h *ReviewHandler) GetReviewsByProductID(c *fiber.Ctx) error {
 	productIDStr := c.Params("productID")
 	productID, err := strconv.ParseUint(productIDStr, 10, 64)
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid product ID"})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
 	}
 	reviews, err := h.reviewUsecase.GetReviewsByProductID(uint(productID))
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(reviews)
 }
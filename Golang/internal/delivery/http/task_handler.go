@@ -1,7 +1,6 @@
 package http
 
 import (
-	"fmt"
 	"my-go-project/internal/common"
 	"my-go-project/internal/domain"
 	"my-go-project/internal/usecase"
@@ -56,9 +55,7 @@ func DeleteAllTasksHandler(app *fiber.App, uc *usecase.TaskUseCase) {
 func (h *TaskHandler) DeleteAll(c *fiber.Ctx) error {
 	tasks, err := h.usecase.DeleteAllTasks()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to delete all tasks",
-		})
+		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(tasks)
 }
@@ -79,9 +76,7 @@ func (h *TaskHandler) DeleteAll(c *fiber.Ctx) error {
 func (h *TaskHandler) GetAll(c *fiber.Ctx) error {
 	tasks, err := h.usecase.GetAllTasks()
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to retrieve tasks",
-		})
+		return err
 	}
 	return c.Status(fiber.StatusOK).JSON(tasks)
 
@@ -105,26 +100,17 @@ func (h *TaskHandler) GetAll(c *fiber.Ctx) error {
 func (h *TaskHandler) Update(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid task ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid task ID")
 	}
 	task, err := h.usecase.GetTaskByID(uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Task not found",
-		})
+		return err
 	}
 	if err := c.BodyParser(task); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 	if err := h.usecase.UpdateTask(task); err != nil {
-		fmt.Println("DEBUG: error =", err.Error())
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to update task",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(task)
@@ -153,15 +139,11 @@ func DeleteTaskHandler(app *fiber.App, uc *usecase.TaskUseCase) {
 func (h *TaskHandler) Delete(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid task ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid task ID")
 	}
 
 	if err := h.usecase.DeleteTask(uint(id)); err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Task not found",
-		})
+		return err
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
@@ -182,16 +164,12 @@ func (h *TaskHandler) Delete(c *fiber.Ctx) error {
 func (h *TaskHandler) GetByID(c *fiber.Ctx) error {
 	id, err := c.ParamsInt("id")
 	if err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid task ID",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid task ID")
 	}
 
 	task, err := h.usecase.GetTaskByID(uint(id))
 	if err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Task not found",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusOK).JSON(task)
@@ -214,16 +192,11 @@ func (h *TaskHandler) GetByID(c *fiber.Ctx) error {
 func (h *TaskHandler) Create(c *fiber.Ctx) error {
 	var task domain.Task
 	if err := c.BodyParser(&task); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid request body")
 	}
 
 	if err := h.usecase.CreateTask(&task); err != nil {
-		fmt.Println("DEBUG: error =", err.Error())
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to create task",
-		})
+		return err
 	}
 
 	return c.Status(fiber.StatusCreated).JSON(task)
@@ -0,0 +1,103 @@
+package http
+
+import (
+	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type VariantHandler struct {
+	usecase *usecase.ProductUseCase
+}
+
+func NewVariantHandler(app *fiber.App, uc *usecase.ProductUseCase) {
+	handler := &VariantHandler{usecase: uc}
+
+	// Public routes
+	app.Get("/v1/products/:id/variants", handler.GetByProduct)
+
+	// Protected routes (staff or admin only)
+	staff := common.RequireRole(domain.RoleStaff, domain.RoleAdmin)
+	app.Post("/v1/products/:id/variants", common.AuthMiddleware, staff, handler.Create)
+	app.Put("/v1/products/:id/variants/:variantId", common.AuthMiddleware, staff, handler.Update)
+	app.Delete("/v1/products/:id/variants/:variantId", common.AuthMiddleware, staff, handler.Delete)
+}
+
+func (h *VariantHandler) GetByProduct(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	variants, err := h.usecase.GetVariants(uint(productID))
+	if err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(variants)
+}
+
+func (h *VariantHandler) Create(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+
+	var variant domain.ProductVariant
+	if err := common.BindAndValidate(c, &variant); err != nil {
+		return err
+	}
+	variant.ID = 0
+	variant.ProductID = uint(productID)
+
+	if err := h.usecase.CreateVariant(&variant); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(variant)
+}
+
+func (h *VariantHandler) Update(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+	variantID, err := strconv.ParseUint(c.Params("variantId"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid variant ID")
+	}
+
+	// PUT replaces the variant, so fields left out are reset
+	var variant domain.ProductVariant
+	if err := common.BindAndValidate(c, &variant); err != nil {
+		return err
+	}
+	variant.ID = uint(variantID)
+	variant.ProductID = uint(productID)
+
+	if err := h.usecase.UpdateVariant(&variant); err != nil {
+		return err
+	}
+
+	return c.Status(fiber.StatusOK).JSON(variant)
+}
+
+func (h *VariantHandler) Delete(c *fiber.Ctx) error {
+	productID, err := strconv.ParseUint(c.Params("id"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid product ID")
+	}
+	variantID, err := strconv.ParseUint(c.Params("variantId"), 10, 32)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "Invalid variant ID")
+	}
+
+	if err := h.usecase.DeleteVariant(uint(productID), uint(variantID)); err != nil {
+		return err
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
@@ -0,0 +1,99 @@
+package domain
+
+import "time"
+
+// AttributeType decides how values of an attribute are entered and filtered.
+type AttributeType string
+
+const (
+	AttributeTypeText    AttributeType = "text"
+	AttributeTypeNumber  AttributeType = "number"
+	AttributeTypeBoolean AttributeType = "boolean"
+	AttributeTypeEnum    AttributeType = "enum"
+)
+
+func (t AttributeType) Valid() bool {
+	switch t {
+	case AttributeTypeText, AttributeTypeNumber, AttributeTypeBoolean, AttributeTypeEnum:
+		return true
+	}
+	return false
+}
+
+// Attribute is a specification shared by the products of a category, such
+// as RAM for laptops or material for T-shirts. Subcategories inherit the
+// attributes of their ancestors, and a code is unique along that chain.
+type Attribute struct {
+	ID         uint          `json:"id" gorm:"primaryKey"`
+	CategoryID uint          `json:"category_id" gorm:"not null;uniqueIndex:idx_attributes_category_code"`
+	Code       string        `json:"code" gorm:"not null;uniqueIndex:idx_attributes_category_code"`
+	Name       string        `json:"name" gorm:"not null"`
+	Type       AttributeType `json:"type" gorm:"not null"`
+	Unit       string        `json:"unit,omitempty"`
+	Options    []string      `json:"options,omitempty" gorm:"type:jsonb;serializer:json"` // allowed values of an enum
+	SortOrder  int           `json:"sort_order" gorm:"not null;default:0"`
+	CreatedAt  time.Time     `json:"created_at"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// ProductAttributeValue is the value of one attribute for one product.
+// Value is the canonical text for every type, e.g. "16", "true" or
+// "cotton"; number attributes also fill Number so ranges can be filtered.
+type ProductAttributeValue struct {
+	ProductID   uint       `json:"-" gorm:"primaryKey"`
+	AttributeID uint       `json:"attribute_id" gorm:"primaryKey;index"`
+	Attribute   *Attribute `json:"attribute,omitempty"`
+	Value       string     `json:"value" gorm:"not null"`
+	Number      *float64   `json:"number,omitempty"`
+}
+
+// AttributeFilter narrows a listing to products whose value for the
+// attribute is one of Values or falls in one of Ranges. Code comes from the
+// request; the usecase resolves it into AttributeID and canonical values.
+type AttributeFilter struct {
+	Code        string
+	AttributeID uint
+	Values      []string
+	Ranges      []NumberRange
+}
+
+// NumberRange is an inclusive range; a nil bound is open.
+type NumberRange struct {
+	Min *float64
+	Max *float64
+}
+
+// AttributeFacet counts the products of a listing per value of one
+// attribute. The counts ignore the filter on that attribute itself, so a
+// sidebar can offer the other values. Min and Max are set for numbers.
+type AttributeFacet struct {
+	Code   string        `json:"code"`
+	Name   string        `json:"name"`
+	Type   AttributeType `json:"type"`
+	Unit   string        `json:"unit,omitempty"`
+	Values []FacetValue  `json:"values"`
+	Min    *float64      `json:"min,omitempty"`
+	Max    *float64      `json:"max,omitempty"`
+}
+
+type FacetValue struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+type AttributeRepository interface {
+	Create(attribute *Attribute) error
+	GetByID(id uint) (*Attribute, error)
+	// GetByCategories returns the attributes of the given categories ordered
+	// by sort order, then name.
+	GetByCategories(categoryIDs []uint) ([]*Attribute, error)
+	Update(attribute *Attribute) error
+	// Delete also removes the attribute's values from every product.
+	Delete(id uint) error
+	CountValues(attributeID uint) (int64, error)
+
+	// SetProductValues replaces every attribute value of the product.
+	SetProductValues(productID uint, values []ProductAttributeValue) error
+	// Facets counts the products matching filter per value of each attribute.
+	Facets(filter ProductFilter, attributes []*Attribute) ([]*AttributeFacet, error)
+}
@@ -3,13 +3,15 @@ package domain
 import "time"
 
 type CartItem struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	CartID    uint      `json:"cart_id" gorm:"not null"`
-	ProductID uint      `json:"product_id" gorm:"not null"`
-	Quantity  int       `json:"quantity" gorm:"not null;default:1"`
-	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	CartID    uint            `json:"cart_id" gorm:"not null"`
+	ProductID uint            `json:"product_id" gorm:"not null"`
+	VariantID *uint           `json:"variant_id,omitempty"`
+	Quantity  int             `json:"quantity" gorm:"not null;default:1"`
+	Product   Product         `json:"product" gorm:"foreignKey:ProductID"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 type Cart struct {
@@ -20,11 +22,13 @@ type Cart struct {
 	UpdatedAt time.Time  `json:"updated_at"`
 }
 
+// Cart lines are identified by product and variant; variantID 0 means the
+// product itself, for products without variants.
 type CartRepository interface {
 	GetByUserID(userID uint) (*Cart, error)
-	AddItem(cartID uint, productID uint, quantity int) error
-	UpdateItemQuantity(cartID uint, productID uint, quantity int) error
-	RemoveItem(cartID uint, productID uint) error
+	AddItem(cartID uint, productID uint, variantID uint, quantity int) error
+	UpdateItemQuantity(cartID uint, productID uint, variantID uint, quantity int) error
+	RemoveItem(cartID uint, productID uint, variantID uint) error
 	ClearCart(cartID uint) error
 	CreateCart(userID uint) error
 }
@@ -0,0 +1,55 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Category groups products. Categories form a tree through ParentID; a
+// product in a subcategory also belongs to every ancestor.
+type Category struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	ParentID  *uint       `json:"parent_id" gorm:"index"`
+	Name      string      `json:"name" gorm:"not null" validate:"required,max=100"`
+	Slug      string      `json:"slug" gorm:"not null;uniqueIndex" validate:"omitempty,max=120"`
+	SortOrder int         `json:"sort_order" gorm:"not null;default:0"`
+	Children  []*Category `json:"children,omitempty" gorm:"-"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+type CategoryRepository interface {
+	Create(category *Category) error
+	GetByID(id uint) (*Category, error)
+	GetBySlug(slug string) (*Category, error)
+	// GetAll returns every category ordered by sort order, then name.
+	GetAll() ([]*Category, error)
+	// Update also renames the category on its products.
+	Update(category *Category) error
+	// Delete also removes the attributes defined on the category.
+	Delete(id uint) error
+	// DescendantIDs returns id and the ids of every category below it.
+	DescendantIDs(id uint) ([]uint, error)
+	// AncestorIDs returns id and the ids of every category above it.
+	AncestorIDs(id uint) ([]uint, error)
+	CountChildren(id uint) (int64, error)
+	CountProducts(id uint) (int64, error)
+}
+
+// Slugify makes a URL-friendly slug from a name: "Men's Shoes" -> "men-s-shoes".
+// Letters outside ASCII are kept, so names in any language stay readable.
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			dash = false
+		} else if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
@@ -0,0 +1,75 @@
+package domain
+
+// ErrorKind classifies domain errors. The HTTP layer maps each kind to a
+// status code, so usecases never need to know about HTTP.
+type ErrorKind string
+
+const (
+	ErrorKindNotFound          ErrorKind = "not_found"
+	ErrorKindConflict          ErrorKind = "conflict"
+	ErrorKindValidation        ErrorKind = "validation_failed"
+	ErrorKindForbidden         ErrorKind = "forbidden"
+	ErrorKindUnauthorized      ErrorKind = "unauthorized"
+	ErrorKindInsufficientStock ErrorKind = "insufficient_stock"
+	// ErrorKindPreconditionFailed means the resource changed since the
+	// client read it, so its write was refused.
+	ErrorKindPreconditionFailed ErrorKind = "precondition_failed"
+)
+
+// Error is a failure the client can act on. Code is a stable,
+// machine-readable identifier such as "product_not_found".
+type Error struct {
+	Kind    ErrorKind
+	Code    string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches on kind, or on kind and code when the target has a code, so
+// both errors.Is(err, ErrNotFound) and errors.Is(err, ErrInvalidCredentials) work.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && (t.Code == "" || t.Code == e.Code)
+}
+
+// Sentinels to test the kind of an error with errors.Is.
+var (
+	ErrNotFound           = &Error{Kind: ErrorKindNotFound}
+	ErrConflict           = &Error{Kind: ErrorKindConflict}
+	ErrValidation         = &Error{Kind: ErrorKindValidation}
+	ErrForbidden          = &Error{Kind: ErrorKindForbidden}
+	ErrUnauthorized       = &Error{Kind: ErrorKindUnauthorized}
+	ErrInsufficientStock  = &Error{Kind: ErrorKindInsufficientStock}
+	ErrPreconditionFailed = &Error{Kind: ErrorKindPreconditionFailed}
+)
+
+func NewNotFoundError(code, message string) *Error {
+	return &Error{Kind: ErrorKindNotFound, Code: code, Message: message}
+}
+
+func NewConflictError(code, message string) *Error {
+	return &Error{Kind: ErrorKindConflict, Code: code, Message: message}
+}
+
+func NewValidationError(code, message string) *Error {
+	return &Error{Kind: ErrorKindValidation, Code: code, Message: message}
+}
+
+func NewForbiddenError(code, message string) *Error {
+	return &Error{Kind: ErrorKindForbidden, Code: code, Message: message}
+}
+
+func NewUnauthorizedError(code, message string) *Error {
+	return &Error{Kind: ErrorKindUnauthorized, Code: code, Message: message}
+}
+
+func NewInsufficientStockError(code, message string) *Error {
+	return &Error{Kind: ErrorKindInsufficientStock, Code: code, Message: message}
+}
+
+func NewPreconditionFailedError(code, message string) *Error {
+	return &Error{Kind: ErrorKindPreconditionFailed, Code: code, Message: message}
+}
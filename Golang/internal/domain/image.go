@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// ProductImage is one picture in a product gallery. URL and Thumbnails are
+// the public addresses of the original and of each fixed-size thumbnail.
+type ProductImage struct {
+	ID          uint              `json:"id" gorm:"primaryKey"`
+	ProductID   uint              `json:"product_id" gorm:"not null;index"`
+	Key         string            `json:"-" gorm:"not null"`
+	ContentType string            `json:"content_type" gorm:"not null"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Position    int               `json:"position" gorm:"not null;default:0"`
+	URL         string            `json:"url" gorm:"not null"`
+	Thumbnails  map[string]string `json:"thumbnails" gorm:"type:jsonb;serializer:json"`
+	CreatedAt   time.Time         `json:"created_at"`
+}
+
+type ProductImageRepository interface {
+	// GetByProductID returns the gallery in display order.
+	GetByProductID(productID uint) ([]*ProductImage, error)
+	// Create appends the image to the end of the gallery.
+	Create(image *ProductImage) error
+	// Delete removes the image and returns it, so its files can be removed.
+	Delete(productID, imageID uint) (*ProductImage, error)
+	// Reorder sets the gallery order; imageIDs must list every image once.
+	Reorder(productID uint, imageIDs []uint) error
+}
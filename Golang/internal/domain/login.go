@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+var ErrInvalidCredentials = NewUnauthorizedError("invalid_credentials", "invalid email or password")
+
+// LoginThrottledError is returned while a login backoff is in effect.
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// AccountLockedError is returned when an account is temporarily locked.
+type AccountLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *AccountLockedError) Error() string {
+	return fmt.Sprintf("account is locked, retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LoginAttemptStore keeps failed login counters and blocks by key
+// (e.g. "email:<email>" or "ip:<ip>").
+type LoginAttemptStore interface {
+	// IncrFailures adds a failure and returns the new count. The counter
+	// expires after window without further failures.
+	IncrFailures(key string, window time.Duration) (int64, error)
+	// Block stops logins for key during d.
+	Block(key string, d time.Duration) error
+	// BlockedFor returns how long key is still blocked, or 0.
+	BlockedFor(key string) (time.Duration, error)
+	Lock(key string, d time.Duration) error
+	LockedFor(key string) (time.Duration, error)
+	// Clear removes the counter, block and lock of each key.
+	Clear(keys ...string) error
+}
@@ -0,0 +1,11 @@
+package domain
+
+type MailMessage struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+type Mailer interface {
+	Send(msg MailMessage) error
+}
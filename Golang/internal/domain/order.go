@@ -12,15 +12,70 @@ const (
 	OrderStatusCancelled OrderStatus = "cancelled"
 )
 
-type OrderItem struct {
-	ID        uint      `json:"id" gorm:"primaryKey"`
-	OrderID   uint      `json:"order_id" gorm:"not null"`
-	ProductID uint      `json:"product_id" gorm:"not null"`
-	Quantity  int       `json:"quantity" gorm:"not null"`
-	Price     float64   `json:"price" gorm:"not null"`
-	Product   Product   `json:"product" gorm:"foreignKey:ProductID"`
+// orderTransitions lists where an order can go from each status. Delivered
+// and cancelled orders are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:   {OrderStatusConfirmed, OrderStatusCancelled},
+	OrderStatusConfirmed: {OrderStatusShipped, OrderStatusCancelled},
+	OrderStatusShipped:   {OrderStatusDelivered},
+}
+
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	for _, allowed := range orderTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Actor is the user behind a change, recorded for auditing.
+type Actor struct {
+	UserID uint
+	Role   Role
+}
+
+// IsStaff reports whether the actor works for the shop rather than buys from it.
+func (a Actor) IsStaff() bool {
+	return a.Role == RoleStaff || a.Role == RoleAdmin
+}
+
+// OrderStatusChange is one entry of an order's history. The first entry,
+// written when the order is placed, has an empty From.
+type OrderStatusChange struct {
+	ID        uint        `json:"id" gorm:"primaryKey"`
+	OrderID   uint        `json:"order_id" gorm:"not null;index"`
+	From      OrderStatus `json:"from,omitempty"`
+	To        OrderStatus `json:"to" gorm:"not null"`
+	ActorID   *uint       `json:"actor_id,omitempty"`
+	ActorRole Role        `json:"actor_role,omitempty"`
+	Reason    string      `json:"reason,omitempty"`
+	// Restocked is set when the change gave the order's stock back.
+	Restocked bool      `json:"restocked,omitempty" gorm:"not null;default:false"`
 	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type OrderItem struct {
+	ID        uint            `json:"id" gorm:"primaryKey"`
+	OrderID   uint            `json:"order_id" gorm:"not null"`
+	ProductID uint            `json:"product_id" gorm:"not null"`
+	VariantID *uint           `json:"variant_id,omitempty"`
+	SKU       string          `json:"sku,omitempty"`
+	Quantity  int             `json:"quantity" gorm:"not null"`
+	Price     float64         `json:"price" gorm:"not null"`
+	Product   Product         `json:"product" gorm:"foreignKey:ProductID"`
+	Variant   *ProductVariant `json:"variant,omitempty" gorm:"foreignKey:VariantID"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
 }
 
 type Order struct {
@@ -30,14 +85,22 @@ type Order struct {
 	TotalAmount     float64     `json:"total_amount" gorm:"not null"`
 	Items           []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
 	ShippingAddress string      `json:"shipping_address"`
-	CreatedAt       time.Time   `json:"created_at"`
-	UpdatedAt       time.Time   `json:"updated_at"`
+	// StockReserved is set on orders whose stock was taken at checkout, so
+	// cancelling them gives it back. Older orders never took any.
+	StockReserved bool      `json:"-" gorm:"not null;default:false"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
 }
 
 type OrderRepository interface {
 	Create(order *Order) error
 	GetByID(id uint) (*Order, error)
 	GetByUserID(userID uint) ([]*Order, error)
-	UpdateStatus(id uint, status OrderStatus) error
+	// TransitionStatus moves the order from one status to another. It fails
+	// with ErrConflict if the order is no longer in status from.
+	TransitionStatus(id uint, from, to OrderStatus) error
+	AddHistory(change *OrderStatusChange) error
+	// GetHistory returns the status changes of the order, oldest first.
+	GetHistory(orderID uint) ([]*OrderStatusChange, error)
 	GetAll() ([]*Order, error)
 }
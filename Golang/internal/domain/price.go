@@ -0,0 +1,39 @@
+package domain
+
+import "time"
+
+// PriceSource tells how an entry got into a product's price timeline.
+type PriceSource string
+
+const (
+	PriceSourceCreate   PriceSource = "create"
+	PriceSourceUpdate   PriceSource = "update"
+	PriceSourceImport   PriceSource = "import"
+	PriceSourceSchedule PriceSource = "schedule"
+)
+
+// ProductPrice is one entry of a product's price timeline. Entries written
+// by creates, updates and imports take effect at once. Scheduled entries
+// take effect at EffectiveAt; AppliedAt is set when the product price has
+// actually been changed, and stays nil while the entry is pending.
+type ProductPrice struct {
+	ID          uint        `json:"id" gorm:"primaryKey"`
+	ProductID   uint        `json:"product_id" gorm:"not null;index:idx_product_prices_product_effective"`
+	Price       float64     `json:"price" gorm:"not null"`
+	Source      PriceSource `json:"source" gorm:"not null"`
+	EffectiveAt time.Time   `json:"effective_at" gorm:"not null;index:idx_product_prices_product_effective"`
+	AppliedAt   *time.Time  `json:"applied_at" gorm:"index"`
+	CreatedAt   time.Time   `json:"created_at"`
+}
+
+type PriceRepository interface {
+	// Timeline returns every entry of the product, oldest first, pending
+	// scheduled entries last.
+	Timeline(productID uint) ([]*ProductPrice, error)
+	Schedule(price *ProductPrice) error
+	// Cancel deletes a pending scheduled entry.
+	Cancel(productID, priceID uint) error
+	// ApplyDue sets the price of every product with scheduled entries due
+	// at now to the latest of them, and returns how many products changed.
+	ApplyDue(now time.Time) (int, error)
+}
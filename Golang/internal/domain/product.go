@@ -3,23 +3,175 @@ package domain
 import "time"
 
 type Product struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	Price       float64   `json:"price" gorm:"not null"`
-	ImageURL    string    `json:"image_url"`
-	Category    string    `json:"category"`
-	Stock       int       `json:"stock" gorm:"default:0"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint                    `json:"id" gorm:"primaryKey"`
+	SKU         *string                 `json:"sku,omitempty" gorm:"uniqueIndex" validate:"omitempty,min=1,max=64"`
+	Name        string                  `json:"name" gorm:"not null" validate:"required,max=200"`
+	Description string                  `json:"description" validate:"max=5000"`
+	Price       float64                 `json:"price" gorm:"not null" validate:"gt=0"`
+	ImageURL    string                  `json:"image_url" validate:"omitempty,url"`
+	CategoryID  *uint                   `json:"category_id" gorm:"index"`
+	Category    string                  `json:"category" validate:"max=100"`             // name of CategoryID, kept in sync for search
+	Stock       int                     `json:"stock" gorm:"default:0" validate:"gte=0"` // ignored when the product has variants
+	Variants    []ProductVariant        `json:"variants,omitempty" gorm:"foreignKey:ProductID" validate:"-"`
+	Images      []ProductImage          `json:"images,omitempty" gorm:"foreignKey:ProductID" validate:"-"`
+	Attributes  []ProductAttributeValue `json:"attributes,omitempty" gorm:"foreignKey:ProductID" validate:"-"`
+	Status      ProductStatus           `json:"status" gorm:"not null;default:'active';index" validate:"omitempty,oneof=draft active archived"`
+	ArchivedAt  *time.Time              `json:"archived_at,omitempty"`
+	Version     int                     `json:"version" gorm:"not null;default:1"` // bumped by every write, served as the ETag
+	CreatedAt   time.Time               `json:"created_at"`
+	UpdatedAt   time.Time               `json:"updated_at"`
+}
+
+// ProductStatus is where a product is in its lifecycle. Only active products
+// are listed publicly and can be bought. Archiving is the soft delete: the
+// row stays, so orders placed earlier still resolve their items.
+type ProductStatus string
+
+const (
+	ProductStatusDraft    ProductStatus = "draft"
+	ProductStatusActive   ProductStatus = "active"
+	ProductStatusArchived ProductStatus = "archived"
+)
+
+func (s ProductStatus) Valid() bool {
+	switch s {
+	case ProductStatusDraft, ProductStatusActive, ProductStatusArchived:
+		return true
+	}
+	return false
+}
+
+// Variant returns the variant with the given id, or nil if the product has no such variant.
+func (p *Product) Variant(id uint) *ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// ProductSort is a field products can be ordered by.
+type ProductSort string
+
+const (
+	ProductSortID        ProductSort = "id"
+	ProductSortPrice     ProductSort = "price"
+	ProductSortName      ProductSort = "name"
+	ProductSortCreatedAt ProductSort = "created_at"
+)
+
+func (s ProductSort) Valid() bool {
+	switch s {
+	case ProductSortID, ProductSortPrice, ProductSortName, ProductSortCreatedAt:
+		return true
+	}
+	return false
+}
+
+// ProductFilter selects one page of products. Zero values mean "no filter".
+// Category is a slug that the usecase resolves into CategoryIDs, the
+// category and its descendants. When Cursor is set it takes precedence
+// over Offset. An empty Status matches products in every status. Attribute
+// filters combine with AND; the values inside one filter with OR.
+type ProductFilter struct {
+	Status      ProductStatus
+	Category    string
+	CategoryIDs []uint
+	Name        string
+	MinPrice    *float64
+	MaxPrice    *float64
+	InStock     bool
+	Attributes  []AttributeFilter
+	Sort        ProductSort
+	Desc        bool
+	Limit       int
+	Offset      int
+	Cursor      string
+}
+
+// ProductPage is one page of a product listing. Total counts every product
+// matching the filter, Limit is the page size actually applied and
+// NextCursor is empty on the last page. Facets are only computed for
+// listings of one category, whose attributes are known.
+type ProductPage struct {
+	Products   []*Product
+	Total      int64
+	Limit      int
+	NextCursor string
+	Facets     []*AttributeFacet
+}
+
+// ProductSearchHit is a product matched by full-text search. The highlights
+// wrap matched words in <mark> tags.
+type ProductSearchHit struct {
+	Product
+	Rank       float64           `json:"rank"`
+	Highlights ProductHighlights `json:"highlights"`
+}
+
+type ProductHighlights struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// ProductSearchPage is one page of search hits, best match first.
+type ProductSearchPage struct {
+	Hits  []*ProductSearchHit
+	Total int64
+	Limit int
+}
+
+// ProductSuggestion is an autocomplete candidate. Score is between 0 and 1,
+// higher meaning closer to what was typed.
+type ProductSuggestion struct {
+	ID    uint    `json:"id"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
 }
 
 type ProductRepository interface {
 	Create(product *Product) error
 	GetByID(id uint) (*Product, error)
-	GetAll() ([]*Product, error)
+	List(filter ProductFilter) (*ProductPage, error)
+	// Search requires every word of query to match, each one as a word
+	// prefix, in name, category or description. Sort and Cursor of filter
+	// are ignored.
+	Search(query string, filter ProductFilter) (*ProductSearchPage, error)
+	// Suggest returns names of active products close to text, tolerating
+	// typos. It returns no suggestions rather than an error when it runs out
+	// of time.
+	Suggest(text string, limit int) ([]*ProductSuggestion, error)
+
+	GetVariants(productID uint) ([]*ProductVariant, error)
+	CreateVariant(variant *ProductVariant) error
+	UpdateVariant(variant *ProductVariant) error
+	// DeleteVariant also drops it from carts. Ordered variants cannot be deleted.
+	DeleteVariant(productID, variantID uint) error
+
+	// ReserveStock takes quantity off the stock of the product, or of its
+	// variant when variantID is not 0, in a single conditional update. It
+	// fails with ErrInsufficientStock rather than let stock go negative,
+	// and refuses products that are not active. The updated row stays
+	// locked until the surrounding transaction ends.
+	ReserveStock(productID, variantID uint, quantity int) error
+	// ReleaseStock puts quantity back, e.g. when an order is cancelled.
+	ReleaseStock(productID, variantID uint, quantity int) error
+
+	// UpsertBySKU inserts or updates the products in one transaction,
+	// matching existing rows by SKU. Products whose SKU belongs to an
+	// archived product are left out and their SKUs returned in archived.
+	// An empty Status keeps the status of an existing product and makes a
+	// new one active.
+	UpsertBySKU(products []*Product) (created int, updated int, archived []string, err error)
+	// ForEachBatch calls fn with every product in id order, size at a time.
+	ForEachBatch(size int, fn func([]*Product) error) error
+	// Update only succeeds while the stored version equals product.Version,
+	// which it then increments. Otherwise it fails with ErrPreconditionFailed.
+	// Archived products fail with ErrConflict; only Restore un-archives them.
 	Update(product *Product) error
-	Delete(id uint) error
-	GetByCategory(category string) ([]*Product, error)
-	SearchByName(name string) ([]*Product, error)
+	// Archive soft-deletes the product and drops it from carts. Restore
+	// makes an archived product active again.
+	Archive(id uint) error
+	Restore(id uint) error
 }
@@ -0,0 +1,28 @@
+package domain
+
+// ProductImportRow is one parsed row of a bulk import. Line is its position
+// in the file, for error reports. Rows that could not be parsed carry their
+// Errors and no Product.
+type ProductImportRow struct {
+	Line    int
+	Product *Product
+	Errors  []ImportError
+}
+
+// ImportError is one problem with one row. Field is empty when the problem
+// is with the row as a whole.
+type ImportError struct {
+	Line    int    `json:"line"`
+	SKU     string `json:"sku,omitempty"`
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ImportReport struct {
+	Total   int           `json:"total"`
+	Created int           `json:"created"`
+	Updated int           `json:"updated"`
+	Failed  int           `json:"failed"`
+	Errors  []ImportError `json:"errors"`
+}
@@ -0,0 +1,83 @@
+package domain
+
+import "time"
+
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	ReturnStatusApproved  ReturnStatus = "approved"
+	ReturnStatusRejected  ReturnStatus = "rejected"
+	ReturnStatusReceived  ReturnStatus = "received"
+	ReturnStatusRefunded  ReturnStatus = "refunded"
+)
+
+// returnTransitions lists where a return can go from each status. Rejected
+// and refunded returns are final.
+var returnTransitions = map[ReturnStatus][]ReturnStatus{
+	ReturnStatusRequested: {ReturnStatusApproved, ReturnStatusRejected},
+	ReturnStatusApproved:  {ReturnStatusReceived},
+	ReturnStatusReceived:  {ReturnStatusRefunded},
+}
+
+func (s ReturnStatus) Valid() bool {
+	switch s {
+	case ReturnStatusRequested, ReturnStatusApproved, ReturnStatusRejected, ReturnStatusReceived, ReturnStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo reports whether a return in status s may move to next.
+func (s ReturnStatus) CanTransitionTo(next ReturnStatus) bool {
+	for _, allowed := range returnTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ReturnRequest asks to send back some of the items of a delivered order.
+// RefundAmount is worked out from the prices the items were sold at when
+// the return is requested.
+type ReturnRequest struct {
+	ID           uint         `json:"id" gorm:"primaryKey"`
+	OrderID      uint         `json:"order_id" gorm:"not null;index"`
+	UserID       uint         `json:"user_id" gorm:"not null;index"`
+	Status       ReturnStatus `json:"status" gorm:"not null;default:'requested';index"`
+	Reason       string       `json:"reason"`
+	Items        []ReturnItem `json:"items" gorm:"foreignKey:ReturnRequestID"`
+	RefundAmount float64      `json:"refund_amount" gorm:"not null"`
+	// Note is left by staff when they process the return.
+	Note       string     `json:"note,omitempty"`
+	Restocked  bool       `json:"restocked" gorm:"not null;default:false"`
+	ReceivedAt *time.Time `json:"received_at,omitempty"`
+	RefundedAt *time.Time `json:"refunded_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type ReturnItem struct {
+	ID              uint       `json:"id" gorm:"primaryKey"`
+	ReturnRequestID uint       `json:"return_request_id" gorm:"not null;index"`
+	OrderItemID     uint       `json:"order_item_id" gorm:"not null;index"`
+	Quantity        int        `json:"quantity" gorm:"not null"`
+	Reason          string     `json:"reason,omitempty"`
+	OrderItem       *OrderItem `json:"order_item,omitempty" gorm:"foreignKey:OrderItemID"`
+}
+
+type ReturnRepository interface {
+	Create(ret *ReturnRequest) error
+	GetByID(id uint) (*ReturnRequest, error)
+	GetByUserID(userID uint) ([]*ReturnRequest, error)
+	// GetAll lists returns newest first, only those in status if it is set.
+	GetAll(status ReturnStatus) ([]*ReturnRequest, error)
+	// ReturnedQuantities sums, per order item, the quantities of the order's
+	// returns that were not rejected. It locks the order until the
+	// transaction ends, so returns of one order are checked one at a time.
+	ReturnedQuantities(orderID uint) (map[uint]int, error)
+	// Transition saves the status, note, restock flag and timestamps of ret.
+	// It fails with ErrConflict if the return is no longer in status from.
+	Transition(ret *ReturnRequest, from ReturnStatus) error
+}
@@ -0,0 +1,15 @@
+package domain
+
+import "io"
+
+// FileStorage stores binary objects, such as product images, by key. Keys
+// are slash-separated paths like "products/1/ab12.jpg".
+type FileStorage interface {
+	Put(key string, data []byte, contentType string) error
+	// Open returns the object and its content type, or a not-found error.
+	Open(key string) (io.ReadCloser, string, error)
+	Delete(key string) error
+}
+
+// ErrFileNotFound is returned by FileStorage.Open for a missing key.
+var ErrFileNotFound = NewNotFoundError("file_not_found", "file not found")
@@ -0,0 +1,37 @@
+package domain
+
+import "time"
+
+// One-time token purposes
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMFALogin          = "mfa_login"
+)
+
+// TokenStore keeps server-side state for issued tokens so refresh tokens can
+// be rotated and any token can be revoked before it expires.
+type TokenStore interface {
+	SaveRefreshToken(userID uint, jti string, ttl time.Duration) error
+	// ConsumeRefreshToken deletes the refresh token and reports whether it was still active.
+	ConsumeRefreshToken(userID uint, jti string) (bool, error)
+	RevokeAccessToken(jti string, ttl time.Duration) error
+	IsAccessTokenRevoked(jti string) (bool, error)
+	// RevokeAllForUser invalidates every token issued to the user up to now.
+	RevokeAllForUser(userID uint, ttl time.Duration) error
+	// RevokedBefore returns the Unix time in milliseconds before which the
+	// user's tokens are revoked, or 0 if none are.
+	RevokedBefore(userID uint) (int64, error)
+
+	// UseTOTPStep records step as the last TOTP time step accepted for the
+	// user. It reports false, recording nothing, if that step or a later one
+	// was already accepted, which makes every code single-use. The record
+	// only has to outlive the window in which codes are accepted, ttl.
+	UseTOTPStep(userID uint, step int64, ttl time.Duration) (bool, error)
+
+	// SaveOneTimeToken stores a hashed single-use token for a user.
+	SaveOneTimeToken(purpose, tokenHash string, userID uint, ttl time.Duration) error
+	// ConsumeOneTimeToken deletes the token and returns its user, or 0 if it
+	// does not exist, has expired or was already used.
+	ConsumeOneTimeToken(purpose, tokenHash string) (uint, error)
+}
@@ -0,0 +1,19 @@
+package domain
+
+import "context"
+
+// Repositories are repositories bound to one transaction. They are only
+// valid inside the UnitOfWork.Do call that handed them out.
+type Repositories struct {
+	Products ProductRepository
+	Carts    CartRepository
+	Orders   OrderRepository
+	Returns  ReturnRepository
+}
+
+// UnitOfWork makes work spanning several repositories atomic.
+type UnitOfWork interface {
+	// Do runs fn in a transaction, committed when fn returns nil and rolled
+	// back when it returns an error or panics.
+	Do(ctx context.Context, fn func(repos Repositories) error) error
+}
@@ -1,27 +1,72 @@
 package domain
 
-import "fmt"
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleStaff    Role = "staff"
+	RoleAdmin    Role = "admin"
+)
+
+func (r Role) Valid() bool {
+	switch r {
+	case RoleCustomer, RoleStaff, RoleAdmin:
+		return true
+	}
+	return false
+}
 
 type User struct {
-	ID       uint   `json:"id"`
-	Username string `json:"username"`
-	Email    string `json:"email"`
-	Phone    string `json:"phone"`
-	Password string `json:"password"`
-	Avatar   string `json:"avatar"`
+	ID            uint   `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Password      string `json:"password"`
+	Avatar        string `json:"avatar"`
+	Role          Role   `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	// TOTPSecret is set at enrollment and only used once TOTPEnabled is true
+	TOTPSecret  string `json:"-"`
+	TOTPEnabled bool   `json:"-"`
 }
+
+// UserProfile is the public view of a User. It never carries the password hash.
+type UserProfile struct {
+	ID            uint   `json:"id"`
+	Username      string `json:"username"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Avatar        string `json:"avatar"`
+	Role          Role   `json:"role"`
+	EmailVerified bool   `json:"email_verified"`
+	TOTPEnabled   bool   `json:"two_factor_enabled"`
+}
+
 type UserRepository interface {
 	Create(user *User) error
 	FindByEmail(email string) (*User, error)
+	FindByID(id uint) (*User, error)
+	Update(user *User) error
+	UpdateRole(id uint, role Role) error
+	UpdatePassword(id uint, passwordHash string) error
+	MarkEmailVerified(id uint) error
+	UpdateTOTP(id uint, secret string, enabled bool) error
+	// ReplaceRecoveryCodes drops the user's recovery codes and stores the new hashes.
+	ReplaceRecoveryCodes(userID uint, codeHashes []string) error
+	// ConsumeRecoveryCode deletes a matching code and reports whether it existed.
+	ConsumeRecoveryCode(userID uint, codeHash string) (bool, error)
 	GetAll() ([]*User, error)
 }
 
-func (u *User) ValidateLogin() error {
-	if len(u.Email) == 0 {
-		return fmt.Errorf("email is required")
-	}
-	if len(u.Password) == 0 {
-		return fmt.Errorf("password is required")
+func (u *User) Profile() *UserProfile {
+	return &UserProfile{
+		ID:            u.ID,
+		Username:      u.Username,
+		Email:         u.Email,
+		Phone:         u.Phone,
+		Avatar:        u.Avatar,
+		Role:          u.Role,
+		EmailVerified: u.EmailVerified,
+		TOTPEnabled:   u.TOTPEnabled,
 	}
-	return nil
 }
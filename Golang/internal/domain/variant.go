@@ -0,0 +1,25 @@
+package domain
+
+import "time"
+
+// ProductVariant is one sellable version of a product, such as a T-shirt in
+// size M and color red. A product with variants is stocked and sold only
+// through them.
+type ProductVariant struct {
+	ID        uint              `json:"id" gorm:"primaryKey"`
+	ProductID uint              `json:"product_id" gorm:"not null;index"`
+	SKU       string            `json:"sku" gorm:"not null;uniqueIndex" validate:"required,max=64"`
+	Options   map[string]string `json:"options" gorm:"type:jsonb;serializer:json" validate:"dive,keys,required,max=50,endkeys,required,max=100"`
+	Price     *float64          `json:"price,omitempty" validate:"omitempty,gt=0"` // overrides the product price when set
+	Stock     int               `json:"stock" gorm:"not null;default:0" validate:"gte=0"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// PriceOf returns the variant price, falling back to the product price.
+func (v *ProductVariant) PriceOf(p *Product) float64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return p.Price
+}
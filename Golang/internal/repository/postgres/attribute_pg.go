@@ -0,0 +1,251 @@
+package postgres
+
+import (
+	"errors"
+	"math"
+	"my-go-project/internal/domain"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errAttributeNotFound  = domain.NewNotFoundError("attribute_not_found", "attribute not found")
+	errDuplicateAttribute = domain.NewConflictError("duplicate_attribute", "the category already has an attribute with this code")
+)
+
+// maxFacetValues caps the values listed per facet; free-text attributes can
+// have one value per product.
+const maxFacetValues = 50
+
+type AttributeRepository struct {
+	db *gorm.DB
+}
+
+func NewAttributeRepository(db *gorm.DB) *AttributeRepository {
+	return &AttributeRepository{db: db}
+}
+
+func (r *AttributeRepository) Create(attribute *domain.Attribute) error {
+	err := r.db.Create(attribute).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errDuplicateAttribute
+	}
+	return err
+}
+
+func (r *AttributeRepository) GetByID(id uint) (*domain.Attribute, error) {
+	var attribute domain.Attribute
+	err := r.db.First(&attribute, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errAttributeNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &attribute, nil
+}
+
+func (r *AttributeRepository) GetByCategories(categoryIDs []uint) ([]*domain.Attribute, error) {
+	attributes := []*domain.Attribute{}
+	if len(categoryIDs) == 0 {
+		return attributes, nil
+	}
+	err := r.db.Where("category_id IN ?", categoryIDs).Order("sort_order, name").Find(&attributes).Error
+	return attributes, err
+}
+
+// Update never changes the category or the type, which existing values depend on.
+func (r *AttributeRepository) Update(attribute *domain.Attribute) error {
+	result := r.db.Model(&domain.Attribute{}).
+		Where("id = ?", attribute.ID).
+		Select("code", "name", "unit", "options", "sort_order").
+		Updates(attribute)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return errDuplicateAttribute
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errAttributeNotFound
+	}
+	return nil
+}
+
+func (r *AttributeRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("attribute_id = ?", id).Delete(&domain.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&domain.Attribute{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAttributeNotFound
+		}
+		return nil
+	})
+}
+
+func (r *AttributeRepository) CountValues(attributeID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.ProductAttributeValue{}).Where("attribute_id = ?", attributeID).Count(&count).Error
+	return count, err
+}
+
+func (r *AttributeRepository) SetProductValues(productID uint, values []domain.ProductAttributeValue) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("product_id = ?", productID).Delete(&domain.ProductAttributeValue{}).Error; err != nil {
+			return err
+		}
+		if len(values) == 0 {
+			return nil
+		}
+		for i := range values {
+			values[i].ProductID = productID
+		}
+		return tx.Omit("Attribute").Create(&values).Error
+	})
+}
+
+type facetCountRow struct {
+	AttributeID uint
+	Value       string
+	Number      *float64
+	Count       int64
+}
+
+// Facets runs one grouped count for the attributes the filter does not
+// narrow, and one per attribute it does, leaving out that attribute's own
+// filter so its other values still get counts.
+func (r *AttributeRepository) Facets(filter domain.ProductFilter, attributes []*domain.Attribute) ([]*domain.AttributeFacet, error) {
+	filtered := make(map[uint]bool, len(filter.Attributes))
+	for _, af := range filter.Attributes {
+		filtered[af.AttributeID] = true
+	}
+
+	var rows []facetCountRow
+	var unfiltered []uint
+	for _, a := range attributes {
+		if !filtered[a.ID] {
+			unfiltered = append(unfiltered, a.ID)
+		}
+	}
+	if len(unfiltered) > 0 {
+		if err := r.facetCounts(filter, unfiltered, &rows); err != nil {
+			return nil, err
+		}
+	}
+	for id := range filtered {
+		others := filter
+		others.Attributes = nil
+		for _, af := range filter.Attributes {
+			if af.AttributeID != id {
+				others.Attributes = append(others.Attributes, af)
+			}
+		}
+		if err := r.facetCounts(others, []uint{id}, &rows); err != nil {
+			return nil, err
+		}
+	}
+
+	byAttribute := make(map[uint][]facetCountRow, len(attributes))
+	for _, row := range rows {
+		byAttribute[row.AttributeID] = append(byAttribute[row.AttributeID], row)
+	}
+	facets := make([]*domain.AttributeFacet, 0, len(attributes))
+	for _, a := range attributes {
+		facets = append(facets, buildFacet(a, byAttribute[a.ID]))
+	}
+	return facets, nil
+}
+
+// facetCounts appends the product count per value of the attributes to rows.
+func (r *AttributeRepository) facetCounts(filter domain.ProductFilter, attributeIDs []uint, rows *[]facetCountRow) error {
+	var batch []facetCountRow
+	err := r.db.Table("product_attribute_values AS pav").
+		Select("pav.attribute_id, pav.value, MIN(pav.number) AS number, COUNT(*) AS count").
+		Joins("JOIN products ON products.id = pav.product_id").
+		Scopes(productFilterScope(filter)).
+		Where("pav.attribute_id IN ?", attributeIDs).
+		Group("pav.attribute_id, pav.value").
+		Scan(&batch).Error
+	*rows = append(*rows, batch...)
+	return err
+}
+
+// buildFacet orders numbers by value and everything else by count, keeping
+// the most common values.
+func buildFacet(a *domain.Attribute, rows []facetCountRow) *domain.AttributeFacet {
+	facet := &domain.AttributeFacet{
+		Code:   a.Code,
+		Name:   a.Name,
+		Type:   a.Type,
+		Unit:   a.Unit,
+		Values: []domain.FacetValue{},
+	}
+
+	if a.Type == domain.AttributeTypeNumber {
+		sort.Slice(rows, func(i, j int) bool { return facetNumber(rows[i]) < facetNumber(rows[j]) })
+		if len(rows) > 0 {
+			lo, hi := facetNumber(rows[0]), facetNumber(rows[len(rows)-1])
+			facet.Min, facet.Max = &lo, &hi
+		}
+	} else {
+		sort.Slice(rows, func(i, j int) bool {
+			if rows[i].Count != rows[j].Count {
+				return rows[i].Count > rows[j].Count
+			}
+			return strings.ToLower(rows[i].Value) < strings.ToLower(rows[j].Value)
+		})
+	}
+	if len(rows) > maxFacetValues {
+		rows = rows[:maxFacetValues]
+	}
+	for _, row := range rows {
+		facet.Values = append(facet.Values, domain.FacetValue{Value: row.Value, Count: row.Count})
+	}
+	return facet
+}
+
+func facetNumber(row facetCountRow) float64 {
+	if row.Number == nil {
+		return math.Inf(1)
+	}
+	return *row.Number
+}
+
+// attributeFilterSQL matches products with a value of the attribute that
+// equals one of the filter's values or falls in one of its ranges.
+func attributeFilterSQL(af domain.AttributeFilter) clause.Expr {
+	var conds []string
+	var vars []any
+	if len(af.Values) > 0 {
+		conds = append(conds, "fv.value IN ?")
+		vars = append(vars, af.Values)
+	}
+	for _, rg := range af.Ranges {
+		cond := []string{"fv.number IS NOT NULL"}
+		if rg.Min != nil {
+			cond = append(cond, "fv.number >= ?")
+			vars = append(vars, *rg.Min)
+		}
+		if rg.Max != nil {
+			cond = append(cond, "fv.number <= ?")
+			vars = append(vars, *rg.Max)
+		}
+		conds = append(conds, "("+strings.Join(cond, " AND ")+")")
+	}
+	if len(conds) == 0 {
+		conds = append(conds, "TRUE")
+	}
+	return clause.Expr{
+		SQL: "EXISTS (SELECT 1 FROM product_attribute_values fv WHERE fv.product_id = products.id AND fv.attribute_id = ? AND (" +
+			strings.Join(conds, " OR ") + "))",
+		Vars: append([]any{af.AttributeID}, vars...),
+	}
+}
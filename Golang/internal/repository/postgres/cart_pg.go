@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"errors"
 	"my-go-project/internal/domain"
 
 	"gorm.io/gorm"
@@ -16,7 +17,10 @@ func NewCartRepository(db *gorm.DB) *CartRepository {
 
 func (r *CartRepository) GetByUserID(userID uint) (*domain.Cart, error) {
 	var cart domain.Cart
-	err := r.db.Preload("Items.Product").Where("user_id = ?", userID).First(&cart).Error
+	err := r.db.Preload("Items.Product").Preload("Items.Variant").Where("user_id = ?", userID).First(&cart).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("cart_not_found", "cart not found")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -30,10 +34,22 @@ func (r *CartRepository) CreateCart(userID uint) error {
 	return r.db.Create(cart).Error
 }
 
-func (r *CartRepository) AddItem(cartID uint, productID uint, quantity int) error {
+// cartLine selects the cart item for a product and variant; variantID 0 is
+// the line of a product without variants.
+func cartLine(cartID, productID, variantID uint) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Where("cart_id = ? AND product_id = ?", cartID, productID)
+		if variantID == 0 {
+			return db.Where("variant_id IS NULL")
+		}
+		return db.Where("variant_id = ?", variantID)
+	}
+}
+
+func (r *CartRepository) AddItem(cartID uint, productID uint, variantID uint, quantity int) error {
 	// Check if item already exists
 	var existingItem domain.CartItem
-	err := r.db.Where("cart_id = ? AND product_id = ?", cartID, productID).First(&existingItem).Error
+	err := r.db.Scopes(cartLine(cartID, productID, variantID)).First(&existingItem).Error
 
 	if err == gorm.ErrRecordNotFound {
 		// Create new item
@@ -42,6 +58,9 @@ func (r *CartRepository) AddItem(cartID uint, productID uint, quantity int) erro
 			ProductID: productID,
 			Quantity:  quantity,
 		}
+		if variantID != 0 {
+			cartItem.VariantID = &variantID
+		}
 		return r.db.Create(cartItem).Error
 	} else if err != nil {
 		return err
@@ -52,18 +71,18 @@ func (r *CartRepository) AddItem(cartID uint, productID uint, quantity int) erro
 	return r.db.Save(&existingItem).Error
 }
 
-func (r *CartRepository) UpdateItemQuantity(cartID uint, productID uint, quantity int) error {
+func (r *CartRepository) UpdateItemQuantity(cartID uint, productID uint, variantID uint, quantity int) error {
 	if quantity == 0 {
-		return r.RemoveItem(cartID, productID)
+		return r.RemoveItem(cartID, productID, variantID)
 	}
 
 	return r.db.Model(&domain.CartItem{}).
-		Where("cart_id = ? AND product_id = ?", cartID, productID).
+		Scopes(cartLine(cartID, productID, variantID)).
 		Update("quantity", quantity).Error
 }
 
-func (r *CartRepository) RemoveItem(cartID uint, productID uint) error {
-	return r.db.Where("cart_id = ? AND product_id = ?", cartID, productID).
+func (r *CartRepository) RemoveItem(cartID uint, productID uint, variantID uint) error {
+	return r.db.Scopes(cartLine(cartID, productID, variantID)).
 		Delete(&domain.CartItem{}).Error
 }
 
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"errors"
+	"my-go-project/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+var (
+	errCategoryNotFound = domain.NewNotFoundError("category_not_found", "category not found")
+	errDuplicateSlug    = domain.NewConflictError("duplicate_slug", "a category with this slug already exists")
+)
+
+type CategoryRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryRepository(db *gorm.DB) *CategoryRepository {
+	if err := migrateLegacyCategories(db); err != nil {
+		panic(err)
+	}
+	return &CategoryRepository{db: db}
+}
+
+// migrateLegacyCategories turns the free-text category of products created
+// before categories existed into category rows. Names that only differ in
+// case or punctuation share a slug, so they end up in the same category.
+// It only touches products without a category_id, so it is safe to run on
+// every start.
+func migrateLegacyCategories(db *gorm.DB) error {
+	var names []string
+	err := db.Model(&domain.Product{}).
+		Where("category_id IS NULL AND TRIM(category) <> ''").
+		Distinct().Pluck("TRIM(category)", &names).Error
+	if err != nil || len(names) == 0 {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, name := range names {
+			slug := domain.Slugify(name)
+			if slug == "" {
+				continue
+			}
+			category := domain.Category{Name: name, Slug: slug}
+			if err := tx.Where("slug = ?", slug).FirstOrCreate(&category).Error; err != nil {
+				return err
+			}
+			err := tx.Model(&domain.Product{}).
+				Where("category_id IS NULL AND TRIM(category) = ?", name).
+				Updates(map[string]interface{}{"category_id": category.ID, "category": category.Name}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *CategoryRepository) Create(category *domain.Category) error {
+	err := r.db.Create(category).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errDuplicateSlug
+	}
+	return err
+}
+
+func (r *CategoryRepository) GetByID(id uint) (*domain.Category, error) {
+	var category domain.Category
+	err := r.db.First(&category, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetBySlug(slug string) (*domain.Category, error) {
+	var category domain.Category
+	err := r.db.Where("slug = ?", slug).First(&category).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errCategoryNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (r *CategoryRepository) GetAll() ([]*domain.Category, error) {
+	var categories []*domain.Category
+	err := r.db.Order("sort_order, name").Find(&categories).Error
+	return categories, err
+}
+
+func (r *CategoryRepository) Update(category *domain.Category) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.Category{}).
+			Where("id = ?", category.ID).
+			Select("parent_id", "name", "slug", "sort_order").
+			Updates(category)
+		if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+			return errDuplicateSlug
+		}
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCategoryNotFound
+		}
+		return tx.Model(&domain.Product{}).
+			Where("category_id = ?", category.ID).
+			Update("category", category.Name).Error
+	})
+}
+
+func (r *CategoryRepository) Delete(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("attribute_id IN (SELECT id FROM attributes WHERE category_id = ?)", id).
+			Delete(&domain.ProductAttributeValue{}).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("category_id = ?", id).Delete(&domain.Attribute{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&domain.Category{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errCategoryNotFound
+		}
+		return nil
+	})
+}
+
+func (r *CategoryRepository) DescendantIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		)
+		SELECT id FROM tree`, id).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *CategoryRepository) AncestorIDs(id uint) ([]uint, error) {
+	var ids []uint
+	err := r.db.Raw(`
+		WITH RECURSIVE chain AS (
+			SELECT id, parent_id FROM categories WHERE id = ?
+			UNION
+			SELECT c.id, c.parent_id FROM categories c JOIN chain ch ON c.id = ch.parent_id
+		)
+		SELECT id FROM chain`, id).Scan(&ids).Error
+	return ids, err
+}
+
+func (r *CategoryRepository) CountChildren(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Category{}).Where("parent_id = ?", id).Count(&count).Error
+	return count, err
+}
+
+func (r *CategoryRepository) CountProducts(id uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.Product{}).Where("category_id = ?", id).Count(&count).Error
+	return count, err
+}
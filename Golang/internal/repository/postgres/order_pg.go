@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"errors"
 	"my-go-project/internal/domain"
 
 	"gorm.io/gorm"
@@ -20,7 +21,10 @@ func (r *OrderRepository) Create(order *domain.Order) error {
 
 func (r *OrderRepository) GetByID(id uint) (*domain.Order, error) {
 	var order domain.Order
-	err := r.db.Preload("Items.Product").First(&order, id).Error
+	err := r.db.Preload("Items.Product").Preload("Items.Variant").First(&order, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("order_not_found", "order not found")
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -29,16 +33,36 @@ func (r *OrderRepository) GetByID(id uint) (*domain.Order, error) {
 
 func (r *OrderRepository) GetByUserID(userID uint) ([]*domain.Order, error) {
 	var orders []*domain.Order
-	err := r.db.Preload("Items.Product").Where("user_id = ?", userID).Find(&orders).Error
+	err := r.db.Preload("Items.Product").Preload("Items.Variant").Where("user_id = ?", userID).Find(&orders).Error
 	return orders, err
 }
 
-func (r *OrderRepository) UpdateStatus(id uint, status domain.OrderStatus) error {
-	return r.db.Model(&domain.Order{}).Where("id = ?", id).Update("status", status).Error
+func (r *OrderRepository) TransitionStatus(id uint, from, to domain.OrderStatus) error {
+	result := r.db.Model(&domain.Order{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(id); err != nil {
+			return err
+		}
+		return domain.NewConflictError("order_status_changed", "order status was changed by someone else; reload it and try again")
+	}
+	return nil
+}
+
+func (r *OrderRepository) AddHistory(change *domain.OrderStatusChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *OrderRepository) GetHistory(orderID uint) ([]*domain.OrderStatusChange, error) {
+	history := []*domain.OrderStatusChange{}
+	err := r.db.Where("order_id = ?", orderID).Order("created_at, id").Find(&history).Error
+	return history, err
 }
 
 func (r *OrderRepository) GetAll() ([]*domain.Order, error) {
 	var orders []*domain.Order
-	err := r.db.Preload("Items.Product").Find(&orders).Error
+	err := r.db.Preload("Items.Product").Preload("Items.Variant").Find(&orders).Error
 	return orders, err
 }
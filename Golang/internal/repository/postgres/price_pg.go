@@ -0,0 +1,110 @@
+package postgres
+
+import (
+	"my-go-project/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errScheduledPriceNotFound = domain.NewNotFoundError("scheduled_price_not_found", "no pending scheduled price with this ID")
+
+type PriceRepository struct {
+	db *gorm.DB
+}
+
+func NewPriceRepository(db *gorm.DB) *PriceRepository {
+	return &PriceRepository{db: db}
+}
+
+// recordPrices adds the current price of each product to its timeline. It
+// runs inside the transaction that wrote the price.
+func recordPrices(tx *gorm.DB, source domain.PriceSource, products ...*domain.Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	now := time.Now()
+	entries := make([]*domain.ProductPrice, 0, len(products))
+	for _, p := range products {
+		entries = append(entries, &domain.ProductPrice{
+			ProductID:   p.ID,
+			Price:       p.Price,
+			Source:      source,
+			EffectiveAt: now,
+			AppliedAt:   &now,
+		})
+	}
+	return tx.Create(&entries).Error
+}
+
+func (r *PriceRepository) Timeline(productID uint) ([]*domain.ProductPrice, error) {
+	prices := []*domain.ProductPrice{}
+	err := r.db.Where("product_id = ?", productID).
+		Order("applied_at IS NULL, effective_at, id").
+		Find(&prices).Error
+	return prices, err
+}
+
+func (r *PriceRepository) Schedule(price *domain.ProductPrice) error {
+	price.Source = domain.PriceSourceSchedule
+	price.AppliedAt = nil
+	return r.db.Create(price).Error
+}
+
+func (r *PriceRepository) Cancel(productID, priceID uint) error {
+	result := r.db.Where("id = ? AND product_id = ? AND applied_at IS NULL", priceID, productID).
+		Delete(&domain.ProductPrice{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errScheduledPriceNotFound
+	}
+	return nil
+}
+
+// ApplyDue skips entries locked by another instance running it at the same
+// time. When several entries of a product are due, the latest wins and the
+// earlier ones are marked applied with it.
+func (r *PriceRepository) ApplyDue(now time.Time) (int, error) {
+	var changed int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var due []*domain.ProductPrice
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("applied_at IS NULL AND effective_at <= ?", now).
+			Order("product_id, effective_at, id").
+			Find(&due).Error
+		if err != nil || len(due) == 0 {
+			return err
+		}
+
+		latest := map[uint]*domain.ProductPrice{}
+		var productIDs []uint
+		ids := make([]uint, 0, len(due))
+		for _, p := range due {
+			if _, ok := latest[p.ProductID]; !ok {
+				productIDs = append(productIDs, p.ProductID)
+			}
+			latest[p.ProductID] = p
+			ids = append(ids, p.ID)
+		}
+
+		for _, productID := range productIDs {
+			err := tx.Model(&domain.Product{}).Where("id = ?", productID).Updates(map[string]any{
+				"price":      latest[productID].Price,
+				"version":    gorm.Expr("version + 1"),
+				"updated_at": now,
+			}).Error
+			if err != nil {
+				return err
+			}
+		}
+		changed = len(productIDs)
+		return tx.Model(&domain.ProductPrice{}).Where("id IN ?", ids).Update("applied_at", now).Error
+	})
+	if err != nil {
+		return 0, err
+	}
+	return changed, nil
+}
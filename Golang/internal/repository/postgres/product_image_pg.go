@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"errors"
+	"my-go-project/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errImageNotFound = domain.NewNotFoundError("image_not_found", "image not found")
+
+type ProductImageRepository struct {
+	db *gorm.DB
+}
+
+func NewProductImageRepository(db *gorm.DB) *ProductImageRepository {
+	return &ProductImageRepository{db: db}
+}
+
+func (r *ProductImageRepository) GetByProductID(productID uint) ([]*domain.ProductImage, error) {
+	images := []*domain.ProductImage{}
+	err := r.db.Where("product_id = ?", productID).Order("position, id").Find(&images).Error
+	return images, err
+}
+
+func (r *ProductImageRepository) Create(image *domain.ProductImage) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the product row so concurrent uploads get distinct positions
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").First(&domain.Product{}, image.ProductID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return domain.NewNotFoundError("product_not_found", "product not found")
+			}
+			return err
+		}
+		var next int
+		if err := tx.Model(&domain.ProductImage{}).
+			Where("product_id = ?", image.ProductID).
+			Select("COALESCE(MAX(position) + 1, 0)").Scan(&next).Error; err != nil {
+			return err
+		}
+		image.Position = next
+		return tx.Create(image).Error
+	})
+}
+
+func (r *ProductImageRepository) Delete(productID, imageID uint) (*domain.ProductImage, error) {
+	var image domain.ProductImage
+	err := r.db.Clauses(clause.Returning{}).
+		Where("id = ? AND product_id = ?", imageID, productID).
+		Delete(&image).Error
+	if err != nil {
+		return nil, err
+	}
+	if image.ID == 0 {
+		return nil, errImageNotFound
+	}
+	return &image, nil
+}
+
+func (r *ProductImageRepository) Reorder(productID uint, imageIDs []uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var existing []uint
+		if err := tx.Model(&domain.ProductImage{}).
+			Where("product_id = ?", productID).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if !sameIDs(existing, imageIDs) {
+			return domain.NewValidationError("invalid_image_order", "image_ids must list every image of the product once")
+		}
+		for position, id := range imageIDs {
+			if err := tx.Model(&domain.ProductImage{}).
+				Where("id = ?", id).
+				Update("position", position).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// sameIDs reports whether b is a permutation of a.
+func sameIDs(a, b []uint) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	seen := make(map[uint]int, len(a))
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		if seen[id] == 0 {
+			return false
+		}
+		seen[id]--
+	}
+	return true
+}
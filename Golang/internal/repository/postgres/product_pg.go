@@ -1,10 +1,18 @@
 package postgres
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"my-go-project/internal/domain"
 	"strings"
+	"time"
+	"unicode"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ProductRepository struct {
@@ -12,45 +20,644 @@ type ProductRepository struct {
 }
 
 func NewProductRepository(db *gorm.DB) *ProductRepository {
+	if err := migrateProductSearch(db); err != nil {
+		panic(err)
+	}
 	return &ProductRepository{db: db}
 }
 
+// migrateProductSearch adds the weighted full-text column and its GIN index,
+// and a trigram index on name for typo-tolerant suggestions. Name ranks above
+// category, which ranks above description. The 'simple' configuration does
+// no stemming, so it works for any language.
+func migrateProductSearch(db *gorm.DB) error {
+	return db.Exec(`
+		ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('simple', coalesce(name, '')), 'A') ||
+				setweight(to_tsvector('simple', coalesce(category, '')), 'B') ||
+				setweight(to_tsvector('simple', coalesce(description, '')), 'C')
+			) STORED;
+		CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+		CREATE EXTENSION IF NOT EXISTS pg_trgm;
+		CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (lower(name) gin_trgm_ops);
+	`).Error
+}
+
+var errDuplicateProductSKU = domain.NewConflictError("duplicate_sku", "a product with this SKU already exists")
+
+// Variants, images and attributes are written through their own methods,
+// never with the product. Every write that sets the price also records it
+// in the price history.
 func (r *ProductRepository) Create(product *domain.Product) error {
-	return r.db.Create(product).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Variants", "Images", "Attributes").Create(product).Error; err != nil {
+			return err
+		}
+		return recordPrices(tx, domain.PriceSourceCreate, product)
+	})
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errDuplicateProductSKU
+	}
+	return err
 }
 
 func (r *ProductRepository) GetByID(id uint) (*domain.Product, error) {
 	var product domain.Product
-	err := r.db.First(&product, id).Error
+	err := r.db.Preload("Variants", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order("position, id")
+	}).Preload("Attributes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("attribute_id")
+	}).Preload("Attributes.Attribute").First(&product, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("product_not_found", "product not found")
+	}
 	if err != nil {
 		return nil, err
 	}
 	return &product, nil
 }
 
-func (r *ProductRepository) GetAll() ([]*domain.Product, error) {
-	var products []*domain.Product
-	err := r.db.Find(&products).Error
-	return products, err
+func (r *ProductRepository) List(filter domain.ProductFilter) (*domain.ProductPage, error) {
+	var cur *productCursor
+	if filter.Cursor != "" {
+		var err error
+		if cur, err = decodeProductCursor(filter.Cursor, filter); err != nil {
+			return nil, err
+		}
+	}
+
+	var total int64
+	if err := r.db.Model(&domain.Product{}).Scopes(productFilterScope(filter)).Count(&total).Error; err != nil {
+		return nil, err
+	}
+
+	dir := "ASC"
+	if filter.Desc {
+		dir = "DESC"
+	}
+	col := string(filter.Sort)
+
+	query := r.db.Scopes(productFilterScope(filter))
+	if cur != nil {
+		op := ">"
+		if filter.Desc {
+			op = "<"
+		}
+		// Keyset pagination: id breaks ties so rows with equal sort values are never skipped
+		if filter.Sort == domain.ProductSortID {
+			query = query.Where("id "+op+" ?", cur.ID)
+		} else {
+			query = query.Where(fmt.Sprintf("(%s, id) %s (?, ?)", col, op), cur.value, cur.ID)
+		}
+	} else if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	// One extra row tells whether there is a next page
+	products := make([]*domain.Product, 0, filter.Limit+1)
+	err := query.
+		Order(fmt.Sprintf("%s %s, id %s", col, dir, dir)).
+		Limit(filter.Limit + 1).
+		Find(&products).Error
+	if err != nil {
+		return nil, err
+	}
+
+	page := &domain.ProductPage{Products: products, Total: total, Limit: filter.Limit}
+	if len(products) > filter.Limit {
+		page.Products = products[:filter.Limit]
+		page.NextCursor = encodeProductCursor(page.Products[filter.Limit-1], filter)
+	}
+	return page, nil
 }
 
+const searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+type productSearchRow struct {
+	domain.Product
+	Rank                 float64
+	NameHighlight        string
+	DescriptionHighlight string
+}
+
+func (r *ProductRepository) Search(query string, filter domain.ProductFilter) (*domain.ProductSearchPage, error) {
+	page := &domain.ProductSearchPage{Hits: []*domain.ProductSearchHit{}, Limit: filter.Limit}
+	tsq := toPrefixTSQuery(query)
+	if tsq == "" {
+		return page, nil
+	}
+
+	matches := func(db *gorm.DB) *gorm.DB {
+		return db.Where("search_vector @@ to_tsquery('simple', ?)", tsq)
+	}
+	if err := r.db.Model(&domain.Product{}).Scopes(productFilterScope(filter), matches).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	// Rank and cut the page first so ts_headline, which is slow, only runs on the rows returned
+	ranked := r.db.Model(&domain.Product{}).
+		Scopes(productFilterScope(filter), matches).
+		Select("products.*, ts_rank(search_vector, to_tsquery('simple', ?)) AS rank", tsq).
+		Order("rank DESC, id").
+		Limit(filter.Limit).
+		Offset(filter.Offset)
+
+	var rows []productSearchRow
+	err := r.db.Table("(?) AS p", ranked).
+		Select(`p.*,
+			ts_headline('simple', p.name, to_tsquery('simple', ?), 'HighlightAll=true, StartSel=<mark>, StopSel=</mark>') AS name_highlight,
+			ts_headline('simple', p.description, to_tsquery('simple', ?), ?) AS description_highlight`,
+			tsq, tsq, searchHeadlineOptions).
+		Order("rank DESC, id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		page.Hits = append(page.Hits, &domain.ProductSearchHit{
+			Product: row.Product,
+			Rank:    row.Rank,
+			Highlights: domain.ProductHighlights{
+				Name:        row.NameHighlight,
+				Description: row.DescriptionHighlight,
+			},
+		})
+	}
+	return page, nil
+}
+
+const (
+	// suggestTimeout is the latency budget of one autocomplete query.
+	suggestTimeout = 150 * time.Millisecond
+	// suggestThreshold is low enough for one transposition in a short word,
+	// e.g. "iphnoe" still finds "iPhone 15".
+	suggestThreshold = 0.3
+)
+
+func (r *ProductRepository) Suggest(text string, limit int) ([]*domain.ProductSuggestion, error) {
+	text = strings.ToLower(strings.TrimSpace(text))
+	suggestions := []*domain.ProductSuggestion{}
+	if text == "" {
+		return suggestions, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), suggestTimeout)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// SET LOCAL keeps the threshold from leaking to other queries on the pooled connection
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %g", suggestThreshold)).Error; err != nil {
+			return err
+		}
+		// Both operators are served by the trigram index on lower(name)
+		return tx.Model(&domain.Product{}).
+			Select("id, name, word_similarity(?, lower(name)) AS score", text).
+			Where("? <% lower(name) OR lower(name) LIKE ?", text, escapeLike(text)+"%").
+			Where("status = ?", domain.ProductStatusActive).
+			Order("score DESC, name").
+			Limit(limit).
+			Scan(&suggestions).Error
+	})
+	if err != nil {
+		if ctx.Err() != nil {
+			return []*domain.ProductSuggestion{}, nil
+		}
+		return nil, err
+	}
+	return suggestions, nil
+}
+
+// escapeLike escapes the LIKE wildcards in s.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+// toPrefixTSQuery turns free text into a tsquery that requires every word
+// and matches each one as a prefix, e.g. "red sho" -> "red:* & sho:*". Only
+// letters and digits survive, so the result is always valid tsquery syntax.
+func toPrefixTSQuery(query string) string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, w := range words {
+		words[i] = w + ":*"
+	}
+	return strings.Join(words, " & ")
+}
+
+// productFilterScope applies the WHERE clauses shared by the count and the page query.
+func productFilterScope(filter domain.ProductFilter) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.Status != "" {
+			db = db.Where("status = ?", filter.Status)
+		}
+		if len(filter.CategoryIDs) > 0 {
+			db = db.Where("category_id IN ?", filter.CategoryIDs)
+		}
+		if filter.Name != "" {
+			db = db.Where("LOWER(name) LIKE ?", "%"+escapeLike(strings.ToLower(filter.Name))+"%")
+		}
+		if filter.MinPrice != nil {
+			db = db.Where("price >= ?", *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			db = db.Where("price <= ?", *filter.MaxPrice)
+		}
+		for _, af := range filter.Attributes {
+			db = db.Where(attributeFilterSQL(af))
+		}
+		if filter.InStock {
+			db = db.Where(`CASE WHEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id)
+				THEN EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = products.id AND v.stock > 0)
+				ELSE stock > 0 END`)
+		}
+		return db
+	}
+}
+
+var errInvalidCursor = domain.NewValidationError("invalid_cursor", "cursor is invalid or does not match the requested sort")
+
+// productCursor is the position after the last row of a page. It records the
+// sort it was issued for, so it cannot be replayed against a different order.
+type productCursor struct {
+	Sort  domain.ProductSort `json:"s"`
+	Desc  bool               `json:"d,omitempty"`
+	Value json.RawMessage    `json:"v,omitempty"`
+	ID    uint               `json:"id"`
+	value any
+}
+
+func encodeProductCursor(last *domain.Product, filter domain.ProductFilter) string {
+	var v any
+	switch filter.Sort {
+	case domain.ProductSortPrice:
+		v = last.Price
+	case domain.ProductSortName:
+		v = last.Name
+	case domain.ProductSortCreatedAt:
+		v = last.CreatedAt
+	}
+	cur := productCursor{Sort: filter.Sort, Desc: filter.Desc, ID: last.ID}
+	if v != nil {
+		cur.Value, _ = json.Marshal(v)
+	}
+	raw, _ := json.Marshal(cur)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeProductCursor(s string, filter domain.ProductFilter) (*productCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	var cur productCursor
+	if err := json.Unmarshal(raw, &cur); err != nil {
+		return nil, errInvalidCursor
+	}
+	if cur.Sort != filter.Sort || cur.Desc != filter.Desc {
+		return nil, errInvalidCursor
+	}
+
+	// Decode the sort value into its column type so postgres compares it correctly
+	switch cur.Sort {
+	case domain.ProductSortPrice:
+		var v float64
+		err = json.Unmarshal(cur.Value, &v)
+		cur.value = v
+	case domain.ProductSortName:
+		var v string
+		err = json.Unmarshal(cur.Value, &v)
+		cur.value = v
+	case domain.ProductSortCreatedAt:
+		var v time.Time
+		err = json.Unmarshal(cur.Value, &v)
+		cur.value = v
+	}
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	return &cur, nil
+}
+
+var (
+	errProductVersionMismatch = domain.NewPreconditionFailedError("product_modified", "product was changed by someone else; reload it and try again")
+	errProductArchived        = domain.NewConflictError("product_archived", "archived products cannot be saved; restore the product first")
+)
+
+// Update refuses archived products, which only Restore brings back, and
+// never writes status changes into archived_at.
 func (r *ProductRepository) Update(product *domain.Product) error {
-	return r.db.Save(product).Error
+	version := product.Version
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var stored domain.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "price", "version", "status").
+			First(&stored, product.ID).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.NewNotFoundError("product_not_found", "product not found")
+		}
+		if err != nil {
+			return err
+		}
+		if stored.Version != version {
+			return errProductVersionMismatch
+		}
+		if stored.Status == domain.ProductStatusArchived {
+			return errProductArchived
+		}
+
+		product.Version++
+		// Select("*") writes zero values too, like Save did
+		err = tx.Model(product).
+			Select("*").
+			Omit("ID", "CreatedAt", "ArchivedAt", "Variants", "Images", "Attributes").
+			Updates(product).Error
+		if err != nil {
+			return err
+		}
+		if stored.Price != product.Price {
+			return recordPrices(tx, domain.PriceSourceUpdate, product)
+		}
+		return nil
+	})
+	if err != nil {
+		product.Version = version
+	}
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errDuplicateProductSKU
+	}
+	return err
+}
+
+func (r *ProductRepository) UpsertBySKU(products []*domain.Product) (int, int, []string, error) {
+	if len(products) == 0 {
+		return 0, 0, nil, nil
+	}
+	skus := make([]string, 0, len(products))
+	for _, p := range products {
+		skus = append(skus, *p.SKU)
+	}
+
+	var existing []domain.Product
+	var archived []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Locked so a product cannot be archived between this read and the upsert
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("sku", "price", "status").
+			Where("sku IN ?", skus).
+			Order("id").
+			Find(&existing).Error
+		if err != nil {
+			return err
+		}
+
+		// Archived products only come back through Restore, as with Update
+		isArchived := map[string]bool{}
+		live := existing[:0]
+		for _, p := range existing {
+			if p.Status == domain.ProductStatusArchived {
+				isArchived[*p.SKU] = true
+				archived = append(archived, *p.SKU)
+			} else {
+				live = append(live, p)
+			}
+		}
+		existing = live
+		stored := make(map[string]domain.ProductStatus, len(existing))
+		for _, p := range existing {
+			stored[*p.SKU] = p.Status
+		}
+		for _, p := range products {
+			if p.Status == "" {
+				if status, ok := stored[*p.SKU]; ok {
+					p.Status = status
+				} else {
+					p.Status = domain.ProductStatusActive
+				}
+			}
+		}
+		if len(isArchived) > 0 {
+			kept := make([]*domain.Product, 0, len(products))
+			for _, p := range products {
+				if !isArchived[*p.SKU] {
+					kept = append(kept, p)
+				}
+			}
+			products = kept
+		}
+		if len(products) == 0 {
+			return nil
+		}
+
+		err = tx.Omit("Variants", "Images", "Attributes").Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "sku"}},
+			DoUpdates: append(clause.AssignmentColumns([]string{
+				"name", "description", "price", "image_url", "category_id", "category", "stock", "status", "updated_at",
+			}), clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr("products.version + 1")}),
+		}).Create(&products).Error
+		if err != nil {
+			return err
+		}
+
+		// New products and changed prices go to the price history
+		oldPrices := make(map[string]float64, len(existing))
+		for _, p := range existing {
+			oldPrices[*p.SKU] = p.Price
+		}
+		var changed []*domain.Product
+		for _, p := range products {
+			if old, ok := oldPrices[*p.SKU]; !ok || old != p.Price {
+				changed = append(changed, p)
+			}
+		}
+		return recordPrices(tx, domain.PriceSourceImport, changed...)
+	})
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return len(products) - len(existing), len(existing), archived, nil
+}
+
+func (r *ProductRepository) ForEachBatch(size int, fn func([]*domain.Product) error) error {
+	var batch []*domain.Product
+	return r.db.FindInBatches(&batch, size, func(_ *gorm.DB, _ int) error {
+		return fn(batch)
+	}).Error
+}
+
+var errProductNotArchived = domain.NewConflictError("product_not_archived", "only archived products can be restored")
+
+// Archive keeps the row, so order items keep resolving their product. It is
+// idempotent: archiving an archived product keeps its original archived_at.
+func (r *ProductRepository) Archive(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var product domain.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id", "status").First(&product, id).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.NewNotFoundError("product_not_found", "product not found")
+		}
+		if err != nil {
+			return err
+		}
+		if product.Status == domain.ProductStatusArchived {
+			return nil
+		}
+		if err := tx.Where("product_id = ?", id).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&product).Updates(map[string]any{
+			"status":      domain.ProductStatusArchived,
+			"archived_at": time.Now(),
+			"version":     gorm.Expr("version + 1"),
+		}).Error
+	})
+}
+
+func (r *ProductRepository) Restore(id uint) error {
+	result := r.db.Model(&domain.Product{}).
+		Where("id = ? AND status = ?", id, domain.ProductStatusArchived).
+		Updates(map[string]any{
+			"status":      domain.ProductStatusActive,
+			"archived_at": nil,
+			"version":     gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+	// Tell a missing product apart from one that is not archived
+	if _, err := r.GetByID(id); err != nil {
+		return err
+	}
+	return errProductNotArchived
+}
+
+func (r *ProductRepository) ReserveStock(productID, variantID uint, quantity int) error {
+	var result *gorm.DB
+	if variantID != 0 {
+		result = r.db.Model(&domain.ProductVariant{}).
+			Where("id = ? AND product_id = ? AND stock >= ?", variantID, productID, quantity).
+			Where("EXISTS (SELECT 1 FROM products p WHERE p.id = product_variants.product_id AND p.status = ?)", domain.ProductStatusActive).
+			Update("stock", gorm.Expr("stock - ?", quantity))
+	} else {
+		result = r.db.Model(&domain.Product{}).
+			Where("id = ? AND stock >= ? AND status = ?", productID, quantity, domain.ProductStatusActive).
+			Updates(map[string]any{
+				"stock":   gorm.Expr("stock - ?", quantity),
+				"version": gorm.Expr("version + 1"),
+			})
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil
+	}
+
+	// Nothing matched; read the rows again to tell the caller why
+	product, err := r.GetByID(productID)
+	if err != nil {
+		return err
+	}
+	if product.Status != domain.ProductStatusActive {
+		return domain.NewConflictError("product_unavailable", "product is not available: "+product.Name)
+	}
+	if variantID != 0 {
+		v := product.Variant(variantID)
+		if v == nil {
+			return errVariantNotFound
+		}
+		return domain.NewInsufficientStockError("insufficient_stock", "insufficient stock for variant: "+v.SKU)
+	}
+	return domain.NewInsufficientStockError("insufficient_stock", "insufficient stock for product: "+product.Name)
 }
 
-func (r *ProductRepository) Delete(id uint) error {
-	return r.db.Delete(&domain.Product{}, id).Error
+func (r *ProductRepository) ReleaseStock(productID, variantID uint, quantity int) error {
+	var result *gorm.DB
+	if variantID != 0 {
+		result = r.db.Model(&domain.ProductVariant{}).
+			Where("id = ? AND product_id = ?", variantID, productID).
+			Update("stock", gorm.Expr("stock + ?", quantity))
+	} else {
+		result = r.db.Model(&domain.Product{}).
+			Where("id = ?", productID).
+			Updates(map[string]any{
+				"stock":   gorm.Expr("stock + ?", quantity),
+				"version": gorm.Expr("version + 1"),
+			})
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	// A deleted variant has nothing to give the stock back to
+	if result.RowsAffected == 0 && variantID == 0 {
+		return domain.NewNotFoundError("product_not_found", "product not found")
+	}
+	return nil
 }
 
-func (r *ProductRepository) GetByCategory(category string) ([]*domain.Product, error) {
-	var products []*domain.Product
-	err := r.db.Where("category = ?", category).Find(&products).Error
-	return products, err
+var (
+	errVariantNotFound = domain.NewNotFoundError("variant_not_found", "variant not found")
+	errDuplicateSKU    = domain.NewConflictError("duplicate_sku", "a variant with this SKU already exists")
+	errVariantInOrders = domain.NewConflictError("variant_in_use", "variant has been ordered and cannot be deleted")
+)
+
+func (r *ProductRepository) GetVariants(productID uint) ([]*domain.ProductVariant, error) {
+	variants := []*domain.ProductVariant{}
+	err := r.db.Where("product_id = ?", productID).Order("id").Find(&variants).Error
+	return variants, err
+}
+
+func (r *ProductRepository) CreateVariant(variant *domain.ProductVariant) error {
+	err := r.db.Create(variant).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return errDuplicateSKU
+	}
+	return err
+}
+
+func (r *ProductRepository) UpdateVariant(variant *domain.ProductVariant) error {
+	result := r.db.Model(&domain.ProductVariant{}).
+		Where("id = ? AND product_id = ?", variant.ID, variant.ProductID).
+		Select("sku", "options", "price", "stock").
+		Updates(variant)
+	if errors.Is(result.Error, gorm.ErrDuplicatedKey) {
+		return errDuplicateSKU
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errVariantNotFound
+	}
+	return nil
 }
 
-func (r *ProductRepository) SearchByName(name string) ([]*domain.Product, error) {
-	var products []*domain.Product
-	searchTerm := "%" + strings.ToLower(name) + "%"
-	err := r.db.Where("LOWER(name) LIKE ?", searchTerm).Find(&products).Error
-	return products, err
+func (r *ProductRepository) DeleteVariant(productID, variantID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var ordered int64
+		if err := tx.Model(&domain.OrderItem{}).Where("variant_id = ?", variantID).Count(&ordered).Error; err != nil {
+			return err
+		}
+		if ordered > 0 {
+			return errVariantInOrders
+		}
+		if err := tx.Where("variant_id = ?", variantID).Delete(&domain.CartItem{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("id = ? AND product_id = ?", variantID, productID).Delete(&domain.ProductVariant{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errVariantNotFound
+		}
+		return nil
+	})
 }
@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"errors"
+	"my-go-project/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var errReturnNotFound = domain.NewNotFoundError("return_not_found", "return not found")
+
+type ReturnRepository struct {
+	db *gorm.DB
+}
+
+func NewReturnRepository(db *gorm.DB) *ReturnRepository {
+	return &ReturnRepository{db: db}
+}
+
+func (r *ReturnRepository) Create(ret *domain.ReturnRequest) error {
+	return r.db.Omit("Items.OrderItem").Create(ret).Error
+}
+
+func (r *ReturnRepository) GetByID(id uint) (*domain.ReturnRequest, error) {
+	var ret domain.ReturnRequest
+	err := r.db.Preload("Items.OrderItem").First(&ret, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errReturnNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &ret, nil
+}
+
+func (r *ReturnRepository) GetByUserID(userID uint) ([]*domain.ReturnRequest, error) {
+	returns := []*domain.ReturnRequest{}
+	err := r.db.Preload("Items.OrderItem").Where("user_id = ?", userID).Order("created_at DESC, id DESC").Find(&returns).Error
+	return returns, err
+}
+
+func (r *ReturnRepository) GetAll(status domain.ReturnStatus) ([]*domain.ReturnRequest, error) {
+	returns := []*domain.ReturnRequest{}
+	query := r.db.Preload("Items.OrderItem").Order("created_at DESC, id DESC")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&returns).Error
+	return returns, err
+}
+
+func (r *ReturnRepository) ReturnedQuantities(orderID uint) (map[uint]int, error) {
+	var locked domain.Order
+	err := r.db.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&locked, orderID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.NewNotFoundError("order_not_found", "order not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	err = r.db.Table("return_items AS ri").
+		Select("ri.order_item_id, SUM(ri.quantity) AS quantity").
+		Joins("JOIN return_requests rr ON rr.id = ri.return_request_id").
+		Where("rr.order_id = ? AND rr.status <> ?", orderID, domain.ReturnStatusRejected).
+		Group("ri.order_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	returned := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		returned[row.OrderItemID] = row.Quantity
+	}
+	return returned, nil
+}
+
+func (r *ReturnRepository) Transition(ret *domain.ReturnRequest, from domain.ReturnStatus) error {
+	result := r.db.Model(&domain.ReturnRequest{}).
+		Where("id = ? AND status = ?", ret.ID, from).
+		Select("status", "note", "restocked", "received_at", "refunded_at").
+		Updates(ret)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		if _, err := r.GetByID(ret.ID); err != nil {
+			return err
+		}
+		return domain.NewConflictError("return_status_changed", "return status was changed by someone else; reload it and try again")
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package postgres
 
 import (
+	"errors"
 	"my-go-project/internal/domain"
 
 	"gorm.io/gorm"
@@ -42,6 +43,9 @@ func (r *TaskRepository) Create(task *domain.Task) error {
 func (r *TaskRepository) GetByID(id uint) (*domain.Task, error) {
 	var model Task
 	if err := r.db.First(&model, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.NewNotFoundError("task_not_found", "task not found")
+		}
 		return nil, err
 	}
 	return toEntity(&model), nil
@@ -67,7 +71,14 @@ func (r *TaskRepository) Update(task *domain.Task) error {
 }
 
 func (r *TaskRepository) Delete(id uint) error {
-	return r.db.Delete(&Task{}, id).Error
+	result := r.db.Delete(&Task{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return domain.NewNotFoundError("task_not_found", "task not found")
+	}
+	return nil
 }
 
 func (r *TaskRepository) DeleteAll() ([]domain.Task, error) {
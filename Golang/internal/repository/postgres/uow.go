@@ -0,0 +1,30 @@
+package postgres
+
+import (
+	"context"
+	"my-go-project/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type UnitOfWork struct {
+	db *gorm.DB
+}
+
+func NewUnitOfWork(db *gorm.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// Do hands fn repositories that share one transaction. They are built
+// directly rather than through their constructors, which run migrations.
+// A repository method that opens its own transaction gets a savepoint.
+func (u *UnitOfWork) Do(ctx context.Context, fn func(repos domain.Repositories) error) error {
+	return u.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(domain.Repositories{
+			Products: &ProductRepository{db: tx},
+			Carts:    &CartRepository{db: tx},
+			Orders:   &OrderRepository{db: tx},
+			Returns:  &ReturnRepository{db: tx},
+		})
+	})
+}
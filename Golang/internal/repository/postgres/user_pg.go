@@ -8,28 +8,56 @@ import (
 )
 
 type UserModel struct {
+	ID            uint `gorm:"primaryKey"`
+	Username      string
+	Email         string `gorm:"uniqueIndex"`
+	Phone         string
+	Password      string
+	Avatar        string
+	Role          string `gorm:"not null;default:'customer'"`
+	EmailVerified bool   `gorm:"not null;default:false"`
+	TOTPSecret    string
+	TOTPEnabled   bool      `gorm:"not null;default:false"`
+	CreatedAt     time.Time `gorm:"autoCreateTime"`
+	CreatedBy     uint
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+	UpdatedBy     uint
+}
+
+type RecoveryCodeModel struct {
 	ID        uint   `gorm:"primaryKey"`
-	Email     string `gorm:"uniqueIndex"`
-	Password  string
-	CreatedAt time.Time `gorm:"autoCreateTime"`
-	CreatedBy uint
-	UpdatedAt time.Time `gorm:"autoUpdateTime"`
-	UpdatedBy uint
+	UserID    uint   `gorm:"not null;index"`
+	CodeHash  string `gorm:"not null"`
+	CreatedAt time.Time
 }
 
 func toUserEntity(m *UserModel) *domain.User {
 	return &domain.User{
-		ID:       m.ID,
-		Email:    m.Email,
-		Password: m.Password,
+		ID:            m.ID,
+		Username:      m.Username,
+		Email:         m.Email,
+		Phone:         m.Phone,
+		Password:      m.Password,
+		Avatar:        m.Avatar,
+		Role:          domain.Role(m.Role),
+		EmailVerified: m.EmailVerified,
+		TOTPSecret:    m.TOTPSecret,
+		TOTPEnabled:   m.TOTPEnabled,
 	}
 }
 
 func toUserModel(e *domain.User) *UserModel {
 	return &UserModel{
-		ID:       uint(e.ID),
-		Email:    e.Email,
-		Password: e.Password,
+		ID:            uint(e.ID),
+		Username:      e.Username,
+		Email:         e.Email,
+		Phone:         e.Phone,
+		Password:      e.Password,
+		Avatar:        e.Avatar,
+		Role:          string(e.Role),
+		EmailVerified: e.EmailVerified,
+		TOTPSecret:    e.TOTPSecret,
+		TOTPEnabled:   e.TOTPEnabled,
 	}
 }
 
@@ -38,7 +66,7 @@ type UserPostgresRepo struct {
 }
 
 func NewUserPostgresRepo(db *gorm.DB) domain.UserRepository {
-	if err := db.AutoMigrate(&UserModel{}); err != nil {
+	if err := db.AutoMigrate(&UserModel{}, &RecoveryCodeModel{}); err != nil {
 		panic(err)
 	}
 	return &UserPostgresRepo{DB: db}
@@ -77,3 +105,67 @@ func (r *UserPostgresRepo) FindByEmail(email string) (*domain.User, error) {
 	}
 	return toUserEntity(&model), nil
 }
+
+func (r *UserPostgresRepo) FindByID(id uint) (*domain.User, error) {
+	var model UserModel
+	if err := r.DB.First(&model, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil // No user found
+		}
+		return nil, err
+	}
+	return toUserEntity(&model), nil
+}
+
+// Update saves the profile fields of an existing user.
+func (r *UserPostgresRepo) Update(u *domain.User) error {
+	return r.DB.Model(&UserModel{ID: u.ID}).Updates(map[string]interface{}{
+		"username": u.Username,
+		"phone":    u.Phone,
+		"avatar":   u.Avatar,
+	}).Error
+}
+
+func (r *UserPostgresRepo) UpdateRole(id uint, role domain.Role) error {
+	return r.DB.Model(&UserModel{ID: id}).Update("role", string(role)).Error
+}
+
+func (r *UserPostgresRepo) UpdatePassword(id uint, passwordHash string) error {
+	return r.DB.Model(&UserModel{ID: id}).Update("password", passwordHash).Error
+}
+
+func (r *UserPostgresRepo) MarkEmailVerified(id uint) error {
+	return r.DB.Model(&UserModel{ID: id}).Update("email_verified", true).Error
+}
+
+func (r *UserPostgresRepo) UpdateTOTP(id uint, secret string, enabled bool) error {
+	return r.DB.Model(&UserModel{ID: id}).Updates(map[string]interface{}{
+		"totp_secret":  secret,
+		"totp_enabled": enabled,
+	}).Error
+}
+
+func (r *UserPostgresRepo) ReplaceRecoveryCodes(userID uint, codeHashes []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCodeModel{}).Error; err != nil {
+			return err
+		}
+		if len(codeHashes) == 0 {
+			return nil
+		}
+		models := make([]RecoveryCodeModel, len(codeHashes))
+		for i, hash := range codeHashes {
+			models[i] = RecoveryCodeModel{UserID: userID, CodeHash: hash}
+		}
+		return tx.Create(&models).Error
+	})
+}
+
+func (r *UserPostgresRepo) ConsumeRecoveryCode(userID uint, codeHash string) (bool, error) {
+	// A single DELETE makes each code usable once even under concurrent logins
+	result := r.DB.Where("user_id = ? AND code_hash = ?", userID, codeHash).Delete(&RecoveryCodeModel{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,333 @@
+package usecase
+
+import (
+	"math"
+	"my-go-project/internal/domain"
+	"strconv"
+	"strings"
+)
+
+var errInvalidAttributeID = domain.NewValidationError("invalid_attribute_id", "invalid attribute ID")
+
+const maxAttributeTextLength = 200
+
+type AttributeUseCase struct {
+	Repo         domain.AttributeRepository
+	CategoryRepo domain.CategoryRepository
+	ProductRepo  domain.ProductRepository
+}
+
+func NewAttributeUseCase(r domain.AttributeRepository, categoryRepo domain.CategoryRepository, productRepo domain.ProductRepository) *AttributeUseCase {
+	return &AttributeUseCase{Repo: r, CategoryRepo: categoryRepo, ProductRepo: productRepo}
+}
+
+// GetCategoryAttributes returns the attributes products of the category
+// can have: its own and those inherited from its ancestors.
+func (uc *AttributeUseCase) GetCategoryAttributes(categoryID uint) ([]*domain.Attribute, error) {
+	if categoryID == 0 {
+		return nil, errInvalidCategoryID
+	}
+	if _, err := uc.CategoryRepo.GetByID(categoryID); err != nil {
+		return nil, err
+	}
+	return categoryAttributes(uc.CategoryRepo, uc.Repo, categoryID)
+}
+
+func (uc *AttributeUseCase) CreateAttribute(a *domain.Attribute) error {
+	if a.CategoryID == 0 {
+		return errInvalidCategoryID
+	}
+	if _, err := uc.CategoryRepo.GetByID(a.CategoryID); err != nil {
+		return err
+	}
+	if a.Type == "" {
+		return domain.NewValidationError("attribute_type_required", "attribute type is required")
+	}
+	if err := prepareAttribute(a); err != nil {
+		return err
+	}
+	if err := uc.checkCodeFree(a); err != nil {
+		return err
+	}
+	return uc.Repo.Create(a)
+}
+
+// UpdateAttribute keeps the category and the type, since the values
+// products already have were checked against them.
+func (uc *AttributeUseCase) UpdateAttribute(a *domain.Attribute) error {
+	if a.ID == 0 {
+		return errInvalidAttributeID
+	}
+	current, err := uc.Repo.GetByID(a.ID)
+	if err != nil {
+		return err
+	}
+	if a.Type != "" && a.Type != current.Type {
+		return domain.NewValidationError("attribute_type_locked", "the type of an attribute cannot be changed")
+	}
+	a.CategoryID = current.CategoryID
+	a.Type = current.Type
+	if err := prepareAttribute(a); err != nil {
+		return err
+	}
+	if a.Code != current.Code {
+		if err := uc.checkCodeFree(a); err != nil {
+			return err
+		}
+	}
+	return uc.Repo.Update(a)
+}
+
+func (uc *AttributeUseCase) DeleteAttribute(id uint) error {
+	if id == 0 {
+		return errInvalidAttributeID
+	}
+	return uc.Repo.Delete(id)
+}
+
+// SetProductAttributes replaces the attribute values of a product. values
+// is keyed by attribute code; a null value leaves the attribute unset.
+func (uc *AttributeUseCase) SetProductAttributes(productID uint, values map[string]any) (*domain.Product, error) {
+	if productID == 0 {
+		return nil, errInvalidProductID
+	}
+	product, err := uc.ProductRepo.GetByID(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes []*domain.Attribute
+	if product.CategoryID != nil {
+		if attributes, err = categoryAttributes(uc.CategoryRepo, uc.Repo, *product.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+	byCode := make(map[string]*domain.Attribute, len(attributes))
+	for _, a := range attributes {
+		byCode[a.Code] = a
+	}
+
+	rows := make([]domain.ProductAttributeValue, 0, len(values))
+	for code, raw := range values {
+		a, ok := byCode[code]
+		if !ok {
+			return nil, domain.NewValidationError("unknown_attribute", "the product's category has no attribute: "+code)
+		}
+		if raw == nil {
+			continue
+		}
+		value, number, err := canonicalAttributeValue(a, raw)
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, domain.ProductAttributeValue{AttributeID: a.ID, Value: value, Number: number})
+	}
+
+	if err := uc.Repo.SetProductValues(productID, rows); err != nil {
+		return nil, err
+	}
+	return uc.ProductRepo.GetByID(productID)
+}
+
+// checkCodeFree rejects a code already used above or below the category,
+// where it would make attribute filters ambiguous.
+func (uc *AttributeUseCase) checkCodeFree(a *domain.Attribute) error {
+	ancestors, err := uc.CategoryRepo.AncestorIDs(a.CategoryID)
+	if err != nil {
+		return err
+	}
+	descendants, err := uc.CategoryRepo.DescendantIDs(a.CategoryID)
+	if err != nil {
+		return err
+	}
+	related, err := uc.Repo.GetByCategories(append(ancestors, descendants...))
+	if err != nil {
+		return err
+	}
+	for _, other := range related {
+		if other.Code == a.Code && other.ID != a.ID {
+			return domain.NewConflictError("duplicate_attribute", "a related category already has an attribute with this code")
+		}
+	}
+	return nil
+}
+
+// prepareAttribute derives the code from the name when none is given and
+// checks the options, which only enums have.
+func prepareAttribute(a *domain.Attribute) error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return domain.NewValidationError("attribute_name_required", "attribute name is required")
+	}
+	if !a.Type.Valid() {
+		return domain.NewValidationError("invalid_attribute_type", "attribute type must be text, number, boolean or enum")
+	}
+	if a.Code == "" {
+		a.Code = a.Name
+	}
+	// Codes appear in query keys such as attr.ram_gb, so they use underscores
+	a.Code = strings.ReplaceAll(domain.Slugify(a.Code), "-", "_")
+	if a.Code == "" {
+		return domain.NewValidationError("invalid_attribute_code", "attribute code must contain a letter or a digit")
+	}
+	a.Unit = strings.TrimSpace(a.Unit)
+
+	if a.Type != domain.AttributeTypeEnum {
+		if len(a.Options) > 0 {
+			return domain.NewValidationError("unexpected_attribute_options", "only enum attributes have options")
+		}
+		a.Options = nil
+		return nil
+	}
+	seen := make(map[string]bool, len(a.Options))
+	options := make([]string, 0, len(a.Options))
+	for _, o := range a.Options {
+		o = strings.TrimSpace(o)
+		if o == "" || seen[strings.ToLower(o)] {
+			continue
+		}
+		seen[strings.ToLower(o)] = true
+		options = append(options, o)
+	}
+	if len(options) == 0 {
+		return domain.NewValidationError("attribute_options_required", "enum attributes need at least one option")
+	}
+	a.Options = options
+	return nil
+}
+
+// categoryAttributes returns the attributes of the category and its ancestors.
+func categoryAttributes(categories domain.CategoryRepository, attributes domain.AttributeRepository, categoryID uint) ([]*domain.Attribute, error) {
+	ids, err := categories.AncestorIDs(categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return attributes.GetByCategories(ids)
+}
+
+// canonicalAttributeValue checks raw against the attribute type and returns
+// the text stored for it, plus the number for number attributes. raw is a
+// decoded JSON value or, for filters, a query string.
+func canonicalAttributeValue(a *domain.Attribute, raw any) (string, *float64, error) {
+	s, isString := raw.(string)
+	if isString {
+		s = strings.TrimSpace(s)
+	}
+
+	switch a.Type {
+	case domain.AttributeTypeNumber:
+		var n float64
+		switch v := raw.(type) {
+		case float64:
+			n = v
+		case string:
+			var err error
+			if n, err = strconv.ParseFloat(s, 64); err != nil {
+				return "", nil, invalidAttributeValue(a, "must be a number")
+			}
+		default:
+			return "", nil, invalidAttributeValue(a, "must be a number")
+		}
+		if math.IsNaN(n) || math.IsInf(n, 0) {
+			return "", nil, invalidAttributeValue(a, "must be a number")
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), &n, nil
+
+	case domain.AttributeTypeBoolean:
+		switch v := raw.(type) {
+		case bool:
+			return strconv.FormatBool(v), nil, nil
+		case string:
+			if b, err := strconv.ParseBool(s); err == nil {
+				return strconv.FormatBool(b), nil, nil
+			}
+		}
+		return "", nil, invalidAttributeValue(a, "must be true or false")
+
+	case domain.AttributeTypeEnum:
+		if isString {
+			for _, o := range a.Options {
+				if strings.EqualFold(o, s) {
+					return o, nil, nil
+				}
+			}
+		}
+		return "", nil, invalidAttributeValue(a, "must be one of: "+strings.Join(a.Options, ", "))
+
+	default:
+		if !isString || s == "" {
+			return "", nil, invalidAttributeValue(a, "must be non-empty text")
+		}
+		if len([]rune(s)) > maxAttributeTextLength {
+			return "", nil, invalidAttributeValue(a, "must be at most "+strconv.Itoa(maxAttributeTextLength)+" characters")
+		}
+		return s, nil, nil
+	}
+}
+
+func invalidAttributeValue(a *domain.Attribute, rule string) error {
+	return domain.NewValidationError("invalid_attribute_value", a.Code+" "+rule)
+}
+
+// resolveAttributeFilters turns the codes and raw query values of filters
+// into attribute ids and canonical values. Number attributes also take
+// ranges such as "8..16", "8.." or "..16".
+func resolveAttributeFilters(filters []domain.AttributeFilter, attributes []*domain.Attribute) error {
+	byCode := make(map[string]*domain.Attribute, len(attributes))
+	for _, a := range attributes {
+		byCode[a.Code] = a
+	}
+
+	for i := range filters {
+		f := &filters[i]
+		a, ok := byCode[f.Code]
+		if !ok {
+			return domain.NewValidationError("unknown_attribute", "the category has no attribute: "+f.Code)
+		}
+		f.AttributeID = a.ID
+
+		raws := f.Values
+		f.Values = nil
+		for _, raw := range raws {
+			if lo, hi, isRange := strings.Cut(raw, ".."); isRange && a.Type == domain.AttributeTypeNumber {
+				rg, err := parseNumberRange(a, lo, hi)
+				if err != nil {
+					return err
+				}
+				f.Ranges = append(f.Ranges, rg)
+				continue
+			}
+			value, _, err := canonicalAttributeValue(a, raw)
+			if err != nil {
+				return err
+			}
+			f.Values = append(f.Values, value)
+		}
+	}
+	return nil
+}
+
+func parseNumberRange(a *domain.Attribute, lo, hi string) (domain.NumberRange, error) {
+	var rg domain.NumberRange
+	for _, bound := range []struct {
+		text string
+		dst  **float64
+	}{{lo, &rg.Min}, {hi, &rg.Max}} {
+		text := strings.TrimSpace(bound.text)
+		if text == "" {
+			continue
+		}
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return rg, invalidAttributeValue(a, "range must look like 8..16")
+		}
+		*bound.dst = &n
+	}
+	if rg.Min == nil && rg.Max == nil {
+		return rg, invalidAttributeValue(a, "range needs at least one bound")
+	}
+	if rg.Min != nil && rg.Max != nil && *rg.Min > *rg.Max {
+		return rg, invalidAttributeValue(a, "range minimum cannot be greater than its maximum")
+	}
+	return rg, nil
+}
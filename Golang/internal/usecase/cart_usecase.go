@@ -19,41 +19,51 @@ func NewCartUseCase(cartRepo domain.CartRepository, productRepo domain.ProductRe
 
 func (uc *CartUseCase) GetCart(userID uint) (*domain.Cart, error) {
 	if userID == 0 {
-		return nil, errors.New("invalid user ID")
+		return nil, errInvalidUserID
 	}
 
 	cart, err := uc.CartRepo.GetByUserID(userID)
-	if err != nil {
+	if errors.Is(err, domain.ErrNotFound) {
 		// If cart doesn't exist, create one
 		if err := uc.CartRepo.CreateCart(userID); err != nil {
 			return nil, err
 		}
 		return uc.CartRepo.GetByUserID(userID)
 	}
+	if err != nil {
+		return nil, err
+	}
 
 	return cart, nil
 }
 
-func (uc *CartUseCase) AddToCart(userID uint, productID uint, quantity int) error {
+// AddToCart adds a product to the cart. variantID is required for products
+// with variants and must be 0 for products without.
+func (uc *CartUseCase) AddToCart(userID uint, productID uint, variantID uint, quantity int) error {
 	if userID == 0 {
-		return errors.New("invalid user ID")
+		return errInvalidUserID
 	}
 	if productID == 0 {
-		return errors.New("invalid product ID")
+		return errInvalidProductID
 	}
 	if quantity <= 0 {
-		return errors.New("quantity must be greater than 0")
+		return domain.NewValidationError("invalid_quantity", "quantity must be greater than 0")
 	}
 
 	// Check if product exists
 	product, err := uc.ProductRepo.GetByID(productID)
 	if err != nil {
-		return errors.New("product not found")
+		return err
+	}
+
+	variant, err := resolveVariant(product, variantID)
+	if err != nil {
+		return err
 	}
 
 	// Check if product has enough stock
-	if product.Stock < quantity {
-		return errors.New("insufficient stock")
+	if err := checkStock(product, variant, quantity); err != nil {
+		return err
 	}
 
 	// Get or create cart
@@ -62,29 +72,34 @@ func (uc *CartUseCase) AddToCart(userID uint, productID uint, quantity int) erro
 		return err
 	}
 
-	return uc.CartRepo.AddItem(cart.ID, productID, quantity)
+	return uc.CartRepo.AddItem(cart.ID, productID, variantID, quantity)
 }
 
-func (uc *CartUseCase) UpdateCartItemQuantity(userID uint, productID uint, quantity int) error {
+func (uc *CartUseCase) UpdateCartItemQuantity(userID uint, productID uint, variantID uint, quantity int) error {
 	if userID == 0 {
-		return errors.New("invalid user ID")
+		return errInvalidUserID
 	}
 	if productID == 0 {
-		return errors.New("invalid product ID")
+		return errInvalidProductID
 	}
 	if quantity < 0 {
-		return errors.New("quantity cannot be negative")
+		return domain.NewValidationError("invalid_quantity", "quantity cannot be negative")
 	}
 
 	// Check if product exists
 	product, err := uc.ProductRepo.GetByID(productID)
 	if err != nil {
-		return errors.New("product not found")
+		return err
+	}
+
+	variant, err := resolveVariant(product, variantID)
+	if err != nil {
+		return err
 	}
 
 	// Check if product has enough stock
-	if product.Stock < quantity {
-		return errors.New("insufficient stock")
+	if err := checkStock(product, variant, quantity); err != nil {
+		return err
 	}
 
 	// Get cart
@@ -94,18 +109,18 @@ func (uc *CartUseCase) UpdateCartItemQuantity(userID uint, productID uint, quant
 	}
 
 	if quantity == 0 {
-		return uc.CartRepo.RemoveItem(cart.ID, productID)
+		return uc.CartRepo.RemoveItem(cart.ID, productID, variantID)
 	}
 
-	return uc.CartRepo.UpdateItemQuantity(cart.ID, productID, quantity)
+	return uc.CartRepo.UpdateItemQuantity(cart.ID, productID, variantID, quantity)
 }
 
-func (uc *CartUseCase) RemoveFromCart(userID uint, productID uint) error {
+func (uc *CartUseCase) RemoveFromCart(userID uint, productID uint, variantID uint) error {
 	if userID == 0 {
-		return errors.New("invalid user ID")
+		return errInvalidUserID
 	}
 	if productID == 0 {
-		return errors.New("invalid product ID")
+		return errInvalidProductID
 	}
 
 	cart, err := uc.GetCart(userID)
@@ -113,12 +128,12 @@ func (uc *CartUseCase) RemoveFromCart(userID uint, productID uint) error {
 		return err
 	}
 
-	return uc.CartRepo.RemoveItem(cart.ID, productID)
+	return uc.CartRepo.RemoveItem(cart.ID, productID, variantID)
 }
 
 func (uc *CartUseCase) ClearCart(userID uint) error {
 	if userID == 0 {
-		return errors.New("invalid user ID")
+		return errInvalidUserID
 	}
 
 	cart, err := uc.GetCart(userID)
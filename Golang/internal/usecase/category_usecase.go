@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"my-go-project/internal/domain"
+	"strings"
+)
+
+var errInvalidCategoryID = domain.NewValidationError("invalid_category_id", "invalid category ID")
+
+type CategoryUseCase struct {
+	Repo domain.CategoryRepository
+}
+
+func NewCategoryUseCase(r domain.CategoryRepository) *CategoryUseCase {
+	return &CategoryUseCase{Repo: r}
+}
+
+// GetTree returns the root categories with their children nested, each
+// level ordered by sort order, then name.
+func (uc *CategoryUseCase) GetTree() ([]*domain.Category, error) {
+	categories, err := uc.Repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[uint]*domain.Category, len(categories))
+	for _, c := range categories {
+		byID[c.ID] = c
+	}
+	roots := []*domain.Category{}
+	for _, c := range categories {
+		if c.ParentID != nil {
+			if parent, ok := byID[*c.ParentID]; ok {
+				parent.Children = append(parent.Children, c)
+				continue
+			}
+		}
+		roots = append(roots, c)
+	}
+	return roots, nil
+}
+
+func (uc *CategoryUseCase) GetCategoryByID(id uint) (*domain.Category, error) {
+	if id == 0 {
+		return nil, errInvalidCategoryID
+	}
+	return uc.Repo.GetByID(id)
+}
+
+func (uc *CategoryUseCase) CreateCategory(c *domain.Category) error {
+	if err := uc.prepare(c); err != nil {
+		return err
+	}
+	if c.ParentID != nil {
+		if _, err := uc.Repo.GetByID(*c.ParentID); err != nil {
+			return err
+		}
+	}
+	return uc.Repo.Create(c)
+}
+
+func (uc *CategoryUseCase) UpdateCategory(c *domain.Category) error {
+	if c.ID == 0 {
+		return errInvalidCategoryID
+	}
+	if err := uc.prepare(c); err != nil {
+		return err
+	}
+	if c.ParentID != nil {
+		if _, err := uc.Repo.GetByID(*c.ParentID); err != nil {
+			return err
+		}
+		// Moving a category below itself would detach the branch from the tree
+		descendants, err := uc.Repo.DescendantIDs(c.ID)
+		if err != nil {
+			return err
+		}
+		for _, id := range descendants {
+			if id == *c.ParentID {
+				return domain.NewValidationError("category_cycle", "a category cannot be moved below itself")
+			}
+		}
+	}
+	return uc.Repo.Update(c)
+}
+
+// DeleteCategory only deletes empty categories, so no product or
+// subcategory is left pointing at a missing parent.
+func (uc *CategoryUseCase) DeleteCategory(id uint) error {
+	if id == 0 {
+		return errInvalidCategoryID
+	}
+	children, err := uc.Repo.CountChildren(id)
+	if err != nil {
+		return err
+	}
+	if children > 0 {
+		return domain.NewConflictError("category_has_children", "category has subcategories")
+	}
+	products, err := uc.Repo.CountProducts(id)
+	if err != nil {
+		return err
+	}
+	if products > 0 {
+		return domain.NewConflictError("category_has_products", "category has products")
+	}
+	return uc.Repo.Delete(id)
+}
+
+// prepare trims the name and derives the slug from it when none is given.
+func (uc *CategoryUseCase) prepare(c *domain.Category) error {
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return domain.NewValidationError("category_name_required", "category name is required")
+	}
+	if c.Slug == "" {
+		c.Slug = c.Name
+	}
+	c.Slug = domain.Slugify(c.Slug)
+	if c.Slug == "" {
+		return domain.NewValidationError("invalid_slug", "slug must contain a letter or a digit")
+	}
+	return nil
+}
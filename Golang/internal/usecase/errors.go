@@ -0,0 +1,13 @@
+package usecase
+
+import "my-go-project/internal/domain"
+
+// Validation errors shared by several usecases.
+var (
+	errInvalidUserID    = domain.NewValidationError("invalid_user_id", "invalid user ID")
+	errInvalidProductID = domain.NewValidationError("invalid_product_id", "invalid product ID")
+	errInvalidOrderID   = domain.NewValidationError("invalid_order_id", "invalid order ID")
+	errInvalidVariantID = domain.NewValidationError("invalid_variant_id", "invalid variant ID")
+
+	errInvalidProductStatus = domain.NewValidationError("invalid_product_status", "product status must be draft, active or archived")
+)
@@ -0,0 +1,214 @@
+package usecase
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" // register decoders for image.Decode
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"my-go-project/internal/domain"
+	"my-go-project/pkg/imaging"
+	"net/http"
+	"strings"
+)
+
+const (
+	// MaxImageSize is the largest accepted upload in bytes.
+	MaxImageSize = 10 << 20
+	// maxImagePixels guards against small files that decode into huge images.
+	maxImagePixels = 40_000_000
+)
+
+// ThumbnailSizes are the square thumbnails generated for every image, by name.
+var ThumbnailSizes = map[string]int{
+	"small":  160,
+	"medium": 480,
+}
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type ImageUseCase struct {
+	Repo        domain.ProductImageRepository
+	ProductRepo domain.ProductRepository
+	Storage     domain.FileStorage
+	// baseURL is prefixed to storage keys to build public image URLs.
+	baseURL string
+}
+
+func NewImageUseCase(repo domain.ProductImageRepository, productRepo domain.ProductRepository, storage domain.FileStorage, baseURL string) *ImageUseCase {
+	return &ImageUseCase{
+		Repo:        repo,
+		ProductRepo: productRepo,
+		Storage:     storage,
+		baseURL:     strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+func (uc *ImageUseCase) GetImages(productID uint) ([]*domain.ProductImage, error) {
+	if productID == 0 {
+		return nil, errInvalidProductID
+	}
+	return uc.Repo.GetByProductID(productID)
+}
+
+// UploadImage stores the original and its thumbnails, then appends the image
+// to the product gallery. Keys are derived from the content hash, so stored
+// files never change and can be cached forever.
+func (uc *ImageUseCase) UploadImage(productID uint, data []byte) (*domain.ProductImage, error) {
+	if productID == 0 {
+		return nil, errInvalidProductID
+	}
+	if _, err := uc.ProductRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	if len(data) > MaxImageSize {
+		return nil, domain.NewValidationError("image_too_large", fmt.Sprintf("image must be at most %d MB", MaxImageSize>>20))
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := imageExtensions[contentType]
+	if !ok {
+		return nil, domain.NewValidationError("unsupported_image_type", "image must be a JPEG, PNG or GIF")
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.NewValidationError("invalid_image", "image could not be decoded")
+	}
+	if cfg.Width*cfg.Height > maxImagePixels {
+		return nil, domain.NewValidationError("image_too_large", "image has too many pixels")
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, domain.NewValidationError("invalid_image", "image could not be decoded")
+	}
+
+	sum := sha256.Sum256(data)
+	base := fmt.Sprintf("products/%d/%s", productID, hex.EncodeToString(sum[:16]))
+	key := base + ext
+
+	// Identical uploads share files, so a duplicate would break deleting either one
+	gallery, err := uc.Repo.GetByProductID(productID)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range gallery {
+		if existing.Key == key {
+			return nil, domain.NewConflictError("duplicate_image", "this image is already in the gallery")
+		}
+	}
+
+	stored := []string{}
+	cleanup := func() {
+		for _, key := range stored {
+			if err := uc.Storage.Delete(key); err != nil {
+				log.Printf("Failed to delete image %s: %v", key, err)
+			}
+		}
+	}
+
+	if err := uc.Storage.Put(key, data, contentType); err != nil {
+		return nil, err
+	}
+	stored = append(stored, key)
+
+	thumbnails := make(map[string]string, len(ThumbnailSizes))
+	for name, size := range ThumbnailSizes {
+		var buf bytes.Buffer
+		if err := jpeg.Encode(&buf, imaging.Thumbnail(img, size), &jpeg.Options{Quality: 85}); err != nil {
+			cleanup()
+			return nil, err
+		}
+		thumbKey := thumbnailKey(base, name)
+		if err := uc.Storage.Put(thumbKey, buf.Bytes(), "image/jpeg"); err != nil {
+			cleanup()
+			return nil, err
+		}
+		stored = append(stored, thumbKey)
+		thumbnails[name] = uc.baseURL + "/" + thumbKey
+	}
+
+	productImage := &domain.ProductImage{
+		ProductID:   productID,
+		Key:         key,
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		URL:         uc.baseURL + "/" + key,
+		Thumbnails:  thumbnails,
+	}
+	if err := uc.Repo.Create(productImage); err != nil {
+		cleanup()
+		return nil, err
+	}
+	return productImage, nil
+}
+
+// UploadImages uploads several images in order, all or nothing: when one
+// fails, the images already added by the call are deleted again.
+func (uc *ImageUseCase) UploadImages(productID uint, files [][]byte) ([]*domain.ProductImage, error) {
+	uploaded := make([]*domain.ProductImage, 0, len(files))
+	for _, data := range files {
+		img, err := uc.UploadImage(productID, data)
+		if err != nil {
+			for _, done := range uploaded {
+				if err := uc.DeleteImage(productID, done.ID); err != nil {
+					log.Printf("Failed to roll back image %d: %v", done.ID, err)
+				}
+			}
+			return nil, err
+		}
+		uploaded = append(uploaded, img)
+	}
+	return uploaded, nil
+}
+
+func (uc *ImageUseCase) ReorderImages(productID uint, imageIDs []uint) ([]*domain.ProductImage, error) {
+	if productID == 0 {
+		return nil, errInvalidProductID
+	}
+	if err := uc.Repo.Reorder(productID, imageIDs); err != nil {
+		return nil, err
+	}
+	return uc.Repo.GetByProductID(productID)
+}
+
+func (uc *ImageUseCase) DeleteImage(productID, imageID uint) error {
+	if productID == 0 {
+		return errInvalidProductID
+	}
+	img, err := uc.Repo.Delete(productID, imageID)
+	if err != nil {
+		return err
+	}
+
+	// The row is gone, so a file left behind is only wasted space
+	base := strings.TrimSuffix(img.Key, imageExtensions[img.ContentType])
+	keys := []string{img.Key}
+	for name := range ThumbnailSizes {
+		keys = append(keys, thumbnailKey(base, name))
+	}
+	for _, key := range keys {
+		if err := uc.Storage.Delete(key); err != nil {
+			log.Printf("Failed to delete image %s: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// OpenImage returns a stored image file and its content type.
+func (uc *ImageUseCase) OpenImage(key string) (io.ReadCloser, string, error) {
+	return uc.Storage.Open(key)
+}
+
+func thumbnailKey(base, name string) string {
+	return base + "_" + name + ".jpg"
+}
@@ -0,0 +1,99 @@
+package usecase
+
+import (
+	"fmt"
+	"my-go-project/internal/domain"
+	"strings"
+	"time"
+)
+
+// Login throttling policy. Failures are counted per email and per client IP.
+// After the free attempts each failure doubles the wait before the next try,
+// and too many failures for one email lock the account for a while.
+const (
+	loginFailureWindow   = 15 * time.Minute
+	emailFreeAttempts    = 3
+	ipFreeAttempts       = 20
+	loginBackoffBase     = 1 * time.Second
+	loginBackoffMax      = 5 * time.Minute
+	accountLockThreshold = 10
+	accountLockDuration  = 30 * time.Minute
+)
+
+func loginEmailKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func loginIPKey(ip string) string {
+	return "ip:" + ip
+}
+
+func loginMFAKey(userID uint) string {
+	return fmt.Sprintf("mfa:%d", userID)
+}
+
+// checkLoginAllowed returns a typed error if the email is locked or either
+// the email or the IP is in backoff.
+func (uc *UserUsecase) checkLoginAllowed(email, ip string) error {
+	locked, err := uc.LoginAttempts.LockedFor(loginEmailKey(email))
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		return &domain.AccountLockedError{RetryAfter: locked}
+	}
+
+	for _, key := range []string{loginEmailKey(email), loginIPKey(ip)} {
+		blocked, err := uc.LoginAttempts.BlockedFor(key)
+		if err != nil {
+			return err
+		}
+		if blocked > 0 {
+			return &domain.LoginThrottledError{RetryAfter: blocked}
+		}
+	}
+	return nil
+}
+
+// recordLoginFailure counts the failure against both the email and the IP
+// before acting on either, so a lock never hides the attempt from the IP
+// counter.
+func (uc *UserUsecase) recordLoginFailure(email, ip string) error {
+	emailFailures, err := uc.LoginAttempts.IncrFailures(loginEmailKey(email), loginFailureWindow)
+	if err != nil {
+		return err
+	}
+	ipFailures, err := uc.LoginAttempts.IncrFailures(loginIPKey(ip), loginFailureWindow)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.applyBackoff(loginIPKey(ip), ipFailures, ipFreeAttempts); err != nil {
+		return err
+	}
+	if emailFailures >= accountLockThreshold {
+		return uc.LoginAttempts.Lock(loginEmailKey(email), accountLockDuration)
+	}
+	return uc.applyBackoff(loginEmailKey(email), emailFailures, emailFreeAttempts)
+}
+
+func (uc *UserUsecase) applyBackoff(key string, failures, free int64) error {
+	if failures < free {
+		return nil
+	}
+	delay := loginBackoffMax
+	// Stop shifting once past the cap to avoid overflowing the duration
+	if exp := failures - free; exp < 16 {
+		delay = min(loginBackoffBase<<exp, loginBackoffMax)
+	}
+	return uc.LoginAttempts.Block(key, delay)
+}
+
+// UnlockAccount clears the lock, backoff and failure counter of a user.
+func (uc *UserUsecase) UnlockAccount(userID uint) error {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	return uc.LoginAttempts.Clear(loginEmailKey(user.Email), loginMFAKey(user.ID))
+}
@@ -0,0 +1,248 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"my-go-project/internal/domain"
+	"my-go-project/pkg/token"
+	"my-go-project/pkg/totp"
+	"strings"
+	"time"
+)
+
+const (
+	totpIssuer         = "ShopOnline"
+	recoveryCodeCount  = 10
+	mfaFreeAttempts    = 3
+	mfaFailureWindow   = 15 * time.Minute
+	mfaLockThreshold   = 10
+	mfaLockDuration    = 30 * time.Minute
+	recoveryCodeLength = 10
+	// totpStepTTL covers the longest time a code is accepted, from Skew
+	// steps before its own to Skew steps after
+	totpStepTTL = (2*totp.Skew + 1) * totp.Period
+)
+
+var (
+	errMFAAlreadyEnabled = domain.NewConflictError("mfa_already_enabled", "two-factor authentication is already enabled")
+	errMFANotEnabled     = domain.NewValidationError("mfa_not_enabled", "two-factor authentication is not enabled")
+)
+
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// EnrollTOTP generates a new secret. Two-factor login stays off until the
+// secret is confirmed with a first code.
+func (uc *UserUsecase) EnrollTOTP(userID uint) (*TOTPEnrollment, error) {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.Repo.UpdateTOTP(userID, secret, false); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URI: totp.URI(totpIssuer, user.Email, secret)}, nil
+}
+
+// ConfirmTOTP enables two-factor login and returns the recovery codes. This
+// is the only time the codes are shown in clear.
+func (uc *UserUsecase) ConfirmTOTP(userID uint, code string) ([]string, error) {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, errMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, domain.NewValidationError("mfa_not_enrolled", "two-factor enrollment has not been started")
+	}
+	ok, err := uc.useTOTPCode(user, strings.TrimSpace(code))
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, domain.NewValidationError("invalid_code", "invalid code")
+	}
+
+	codes, err := uc.replaceRecoveryCodes(userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.Repo.UpdateTOTP(userID, user.TOTPSecret, true); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor login off; it needs a current TOTP or recovery code.
+func (uc *UserUsecase) DisableTOTP(userID uint, code string) error {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return errMFANotEnabled
+	}
+	if err := uc.checkSecondFactor(user, code); err != nil {
+		return err
+	}
+
+	if err := uc.Repo.ReplaceRecoveryCodes(userID, nil); err != nil {
+		return err
+	}
+	return uc.Repo.UpdateTOTP(userID, "", false)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes; it needs a current TOTP code.
+func (uc *UserUsecase) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, errMFANotEnabled
+	}
+	if err := uc.checkSecondFactor(user, code); err != nil {
+		return nil, err
+	}
+	return uc.replaceRecoveryCodes(userID)
+}
+
+// CompleteMFALogin exchanges an mfa_pending token and a TOTP or recovery
+// code for the normal token pair. The pending token is used up by the first
+// attempt, right or wrong, so a wrong code means logging in with the
+// password again.
+func (uc *UserUsecase) CompleteMFALogin(mfaToken, code string) (*LoginResult, error) {
+	claims, err := token.ParseToken(mfaToken, token.TypeMFAPending)
+	if err != nil {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	// Consumed before the code is checked, so neither a captured token nor
+	// concurrent attempts with one token get more than one guess
+	userID, err := uc.Tokens.ConsumeOneTimeToken(domain.TokenPurposeMFALogin, hashToken(claims.ID))
+	if err != nil {
+		return nil, err
+	}
+	if userID == 0 || userID != claims.UserID {
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, domain.ErrInvalidCredentials
+	}
+	if err := uc.checkSecondFactor(user, code); err != nil {
+		return nil, err
+	}
+
+	accessToken, refreshToken, err := uc.issueTokens(user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// checkSecondFactor accepts a TOTP code or a recovery code, and applies the
+// same backoff and lockout as password logins to stop guessing.
+func (uc *UserUsecase) checkSecondFactor(user *domain.User, code string) error {
+	key := loginMFAKey(user.ID)
+	locked, err := uc.LoginAttempts.LockedFor(key)
+	if err != nil {
+		return err
+	}
+	if locked > 0 {
+		return &domain.AccountLockedError{RetryAfter: locked}
+	}
+	blocked, err := uc.LoginAttempts.BlockedFor(key)
+	if err != nil {
+		return err
+	}
+	if blocked > 0 {
+		return &domain.LoginThrottledError{RetryAfter: blocked}
+	}
+
+	code = strings.TrimSpace(code)
+	if ok, err := uc.useTOTPCode(user, code); err != nil {
+		return err
+	} else if ok {
+		return uc.LoginAttempts.Clear(key)
+	}
+	if used, err := uc.Repo.ConsumeRecoveryCode(user.ID, hashToken(normalizeRecoveryCode(code))); err != nil {
+		return err
+	} else if used {
+		return uc.LoginAttempts.Clear(key)
+	}
+
+	failures, err := uc.LoginAttempts.IncrFailures(key, mfaFailureWindow)
+	if err != nil {
+		return err
+	}
+	if failures >= mfaLockThreshold {
+		if err := uc.LoginAttempts.Lock(key, mfaLockDuration); err != nil {
+			return err
+		}
+	} else if err := uc.applyBackoff(key, failures, mfaFreeAttempts); err != nil {
+		return err
+	}
+	return domain.ErrInvalidCredentials
+}
+
+// useTOTPCode accepts a TOTP code once: a code whose time step is not later
+// than the last one accepted for the user is refused, so a code seen by
+// someone else cannot be replayed while it is still valid.
+func (uc *UserUsecase) useTOTPCode(user *domain.User, code string) (bool, error) {
+	step, ok := totp.Match(code, user.TOTPSecret, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return uc.Tokens.UseTOTPStep(user.ID, step, totpStepTTL)
+}
+
+func (uc *UserUsecase) issueMFAToken(userID uint) (string, error) {
+	mfaToken, jti, err := token.GenerateToken(userID, "", token.TypeMFAPending, token.MFATokenTTL)
+	if err != nil {
+		return "", err
+	}
+	if err := uc.Tokens.SaveOneTimeToken(domain.TokenPurposeMFALogin, hashToken(jti), userID, token.MFATokenTTL); err != nil {
+		return "", err
+	}
+	return mfaToken, nil
+}
+
+func (uc *UserUsecase) replaceRecoveryCodes(userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		b := make([]byte, recoveryCodeLength/2)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		raw := hex.EncodeToString(b)
+		codes[i] = raw[:recoveryCodeLength/2] + "-" + raw[recoveryCodeLength/2:]
+		hashes[i] = hashToken(raw)
+	}
+	if err := uc.Repo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// normalizeRecoveryCode lets users type codes with or without the dash.
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(code, "-", ""))
+}
@@ -0,0 +1,103 @@
+package usecase_test
+
+import (
+	"errors"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"my-go-project/pkg/mailer"
+	"my-go-project/pkg/token"
+	"my-go-project/pkg/totp"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// openLoginAttempts never throttles, so the tests exercise the token checks alone.
+type openLoginAttempts struct{}
+
+func (openLoginAttempts) IncrFailures(string, time.Duration) (int64, error) { return 1, nil }
+func (openLoginAttempts) Block(string, time.Duration) error                 { return nil }
+func (openLoginAttempts) BlockedFor(string) (time.Duration, error)          { return 0, nil }
+func (openLoginAttempts) Lock(string, time.Duration) error                  { return nil }
+func (openLoginAttempts) LockedFor(string) (time.Duration, error)           { return 0, nil }
+func (openLoginAttempts) Clear(...string) error                             { return nil }
+
+func (r *memUserRepo) ConsumeRecoveryCode(userID uint, codeHash string) (bool, error) {
+	return false, nil
+}
+
+func (s *memTokenStore) UseTOTPStep(userID uint, step int64, ttl time.Duration) (bool, error) {
+	return true, nil
+}
+
+func (s *memTokenStore) SaveRefreshToken(userID uint, jti string, ttl time.Duration) error {
+	return nil
+}
+
+// newMFATestUsecase returns a usecase with one TOTP-enabled user and the
+// mfa_pending token of a password login by that user.
+func newMFATestUsecase(t *testing.T) (*usecase.UserUsecase, string, string) {
+	t.Helper()
+	token.InitKeys()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatalf("generate secret: %v", err)
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret-pass-1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	repo := &memUserRepo{users: map[uint]*domain.User{
+		7: {ID: 7, Email: "ann@example.com", Password: string(hash), EmailVerified: true, TOTPSecret: secret, TOTPEnabled: true},
+	}}
+	uc := usecase.NewUserUsecase(repo, newMemTokenStore(), openLoginAttempts{}, mailer.NewLogMailer(nil), testAppURL)
+
+	result, err := uc.LoginUser(&domain.User{Email: "ann@example.com", Password: "secret-pass-1"}, "192.0.2.1")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if !result.MFARequired || result.MFAToken == "" {
+		t.Fatalf("login did not ask for a second factor: %+v", result)
+	}
+	return uc, result.MFAToken, secret
+}
+
+func TestCompleteMFALogin(t *testing.T) {
+	uc, mfaToken, secret := newMFATestUsecase(t)
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+
+	result, err := uc.CompleteMFALogin(mfaToken, code)
+	if err != nil {
+		t.Fatalf("complete login: %v", err)
+	}
+	if result.AccessToken == "" || result.RefreshToken == "" {
+		t.Errorf("no token pair issued: %+v", result)
+	}
+	if _, err := uc.CompleteMFALogin(mfaToken, code); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("second use of the pending token: got %v, want invalid credentials", err)
+	}
+}
+
+// A wrong code uses up the pending token, so it can't be used for another guess.
+func TestCompleteMFALoginWrongCodeConsumesToken(t *testing.T) {
+	uc, mfaToken, secret := newMFATestUsecase(t)
+	code, err := totp.Code(secret, time.Now())
+	if err != nil {
+		t.Fatalf("code: %v", err)
+	}
+	wrong := "000000"
+	if wrong == code {
+		wrong = "111111"
+	}
+
+	if _, err := uc.CompleteMFALogin(mfaToken, wrong); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Fatalf("wrong code: got %v, want invalid credentials", err)
+	}
+	if _, err := uc.CompleteMFALogin(mfaToken, code); !errors.Is(err, domain.ErrInvalidCredentials) {
+		t.Errorf("right code after a wrong one: got %v, want invalid credentials", err)
+	}
+}
@@ -1,123 +1,290 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"my-go-project/internal/domain"
+	"sort"
+	"strconv"
+	"strings"
 )
 
+var errCartEmpty = domain.NewValidationError("cart_empty", "cart is empty")
+
+const maxOrderReasonLength = 500
+
+// OrderTransitionHook is a side effect of an order changing status. Hooks
+// run in the transaction of the change, with the order still holding its
+// previous status, so an error from a hook undoes the change. They may note
+// what they did on change, which is recorded after the last hook.
+type OrderTransitionHook func(repos domain.Repositories, order *domain.Order, change *domain.OrderStatusChange) error
+
 type OrderUseCase struct {
 	OrderRepo   domain.OrderRepository
 	CartRepo    domain.CartRepository
 	ProductRepo domain.ProductRepository
+	UoW         domain.UnitOfWork
+
+	hooks map[domain.OrderStatus][]OrderTransitionHook
 }
 
-func NewOrderUseCase(orderRepo domain.OrderRepository, cartRepo domain.CartRepository, productRepo domain.ProductRepository) *OrderUseCase {
-	return &OrderUseCase{
+func NewOrderUseCase(orderRepo domain.OrderRepository, cartRepo domain.CartRepository, productRepo domain.ProductRepository, uow domain.UnitOfWork) *OrderUseCase {
+	uc := &OrderUseCase{
 		OrderRepo:   orderRepo,
 		CartRepo:    cartRepo,
 		ProductRepo: productRepo,
+		UoW:         uow,
 	}
+	uc.OnTransition(domain.OrderStatusCancelled, restockOrder)
+	return uc
 }
 
-func (uc *OrderUseCase) CreateOrder(userID uint, shippingAddress string) (*domain.Order, error) {
-	if userID == 0 {
-		return nil, errors.New("invalid user ID")
-	}
-	if shippingAddress == "" {
-		return nil, errors.New("shipping address is required")
+// OnTransition registers hook to run whenever an order moves to status to.
+// Hooks run in the order they were registered. It is not safe to call once
+// the usecase is serving requests.
+func (uc *OrderUseCase) OnTransition(to domain.OrderStatus, hook OrderTransitionHook) {
+	if uc.hooks == nil {
+		uc.hooks = map[domain.OrderStatus][]OrderTransitionHook{}
 	}
+	uc.hooks[to] = append(uc.hooks[to], hook)
+}
 
-	// Get user's cart
-	cart, err := uc.CartRepo.GetByUserID(userID)
-	if err != nil {
-		return nil, errors.New("cart not found")
+// restockOrder gives back the stock a cancelled order took at checkout.
+func restockOrder(repos domain.Repositories, order *domain.Order, change *domain.OrderStatusChange) error {
+	if !order.StockReserved {
+		return nil
 	}
+	for _, item := range order.Items {
+		if err := repos.Products.ReleaseStock(item.ProductID, variantIDOf(item.VariantID), item.Quantity); err != nil {
+			return err
+		}
+	}
+	change.Restocked = true
+	return nil
+}
 
-	if len(cart.Items) == 0 {
-		return nil, errors.New("cart is empty")
+// CreateOrder turns the user's cart into an order. Reading the cart,
+// reserving stock, writing the order and clearing the cart happen in one
+// transaction, so a failure at any step leaves everything as it was.
+func (uc *OrderUseCase) CreateOrder(ctx context.Context, userID uint, shippingAddress string) (*domain.Order, error) {
+	if userID == 0 {
+		return nil, errInvalidUserID
+	}
+	if shippingAddress == "" {
+		return nil, domain.NewValidationError("shipping_address_required", "shipping address is required")
 	}
 
-	// Calculate total amount and validate stock
-	var totalAmount float64
-	for _, item := range cart.Items {
-		product, err := uc.ProductRepo.GetByID(item.ProductID)
+	var order *domain.Order
+	err := uc.UoW.Do(ctx, func(repos domain.Repositories) error {
+		cart, err := repos.Carts.GetByUserID(userID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return errCartEmpty
+		}
 		if err != nil {
-			return nil, errors.New("product not found")
+			return err
 		}
-
-		if product.Stock < item.Quantity {
-			return nil, errors.New("insufficient stock for product: " + product.Name)
+		if len(cart.Items) == 0 {
+			return errCartEmpty
 		}
 
-		totalAmount += product.Price * float64(item.Quantity)
-	}
-
-	// Create order
-	order := &domain.Order{
-		UserID:          userID,
-		Status:          domain.OrderStatusPending,
-		TotalAmount:     totalAmount,
-		ShippingAddress: shippingAddress,
-	}
+		// Reserving in product, then variant order keeps concurrent checkouts
+		// from locking the same rows in opposite orders and deadlocking
+		items := append([]domain.CartItem(nil), cart.Items...)
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].ProductID != items[j].ProductID {
+				return items[i].ProductID < items[j].ProductID
+			}
+			return variantIDOf(items[i].VariantID) < variantIDOf(items[j].VariantID)
+		})
 
-	// Create order items
-	for _, item := range cart.Items {
-		product, err := uc.ProductRepo.GetByID(item.ProductID)
-		if err != nil {
-			return nil, err
+		order = &domain.Order{
+			UserID:          userID,
+			Status:          domain.OrderStatusPending,
+			ShippingAddress: shippingAddress,
+			StockReserved:   true,
+		}
+		for _, item := range items {
+			orderItem, err := orderItemFor(repos.Products, item)
+			if err != nil {
+				return err
+			}
+			if err := repos.Products.ReserveStock(item.ProductID, variantIDOf(item.VariantID), item.Quantity); err != nil {
+				return err
+			}
+			order.Items = append(order.Items, *orderItem)
+			order.TotalAmount += orderItem.Price * float64(item.Quantity)
 		}
 
-		orderItem := domain.OrderItem{
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
+		if err := repos.Orders.Create(order); err != nil {
+			return err
 		}
-		order.Items = append(order.Items, orderItem)
+		err = repos.Orders.AddHistory(&domain.OrderStatusChange{
+			OrderID: order.ID,
+			To:      order.Status,
+			ActorID: &userID,
+			Reason:  "order placed",
+		})
+		if err != nil {
+			return err
+		}
+		return repos.Carts.ClearCart(cart.ID)
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	if err := uc.OrderRepo.Create(order); err != nil {
+	return order, nil
+}
+
+// orderItemFor checks a cart line and snapshots the SKU and price it is
+// sold at. Stock is only checked here to fail early; ReserveStock has the
+// final say.
+func orderItemFor(products domain.ProductRepository, item domain.CartItem) (*domain.OrderItem, error) {
+	product, err := products.GetByID(item.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	variant, err := resolveVariant(product, variantIDOf(item.VariantID))
+	if err != nil {
+		return nil, err
+	}
+	if err := checkStock(product, variant, item.Quantity); err != nil {
 		return nil, err
 	}
 
-	// Clear cart after successful order creation
-	if err := uc.CartRepo.ClearCart(cart.ID); err != nil {
-		// Log error but don't fail the order creation
-		// In production, you might want to handle this differently
+	orderItem := &domain.OrderItem{
+		ProductID: item.ProductID,
+		VariantID: item.VariantID,
+		Quantity:  item.Quantity,
+		Price:     product.Price,
+	}
+	if variant != nil {
+		orderItem.SKU = variant.SKU
+		orderItem.Price = variant.PriceOf(product)
 	}
+	return orderItem, nil
+}
 
-	return order, nil
+// variantIDOf maps a nullable variant reference to the 0-means-none form
+// the repositories take.
+func variantIDOf(id *uint) uint {
+	if id == nil {
+		return 0
+	}
+	return *id
 }
 
 func (uc *OrderUseCase) GetOrderByID(id uint) (*domain.Order, error) {
 	if id == 0 {
-		return nil, errors.New("invalid order ID")
+		return nil, errInvalidOrderID
 	}
 
 	return uc.OrderRepo.GetByID(id)
 }
 
+// GetUserOrder returns an order only if it belongs to the user.
+func (uc *OrderUseCase) GetUserOrder(userID, id uint) (*domain.Order, error) {
+	order, err := uc.GetOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, domain.NewForbiddenError("order_forbidden", "access denied")
+	}
+	return order, nil
+}
+
 func (uc *OrderUseCase) GetOrdersByUserID(userID uint) ([]*domain.Order, error) {
 	if userID == 0 {
-		return nil, errors.New("invalid user ID")
+		return nil, errInvalidUserID
 	}
 
 	return uc.OrderRepo.GetByUserID(userID)
 }
 
-func (uc *OrderUseCase) UpdateOrderStatus(id uint, status domain.OrderStatus) error {
+// TransitionOrder moves an order to status to, if the state machine allows
+// it from where the order is, records the change and runs the hooks for to.
+func (uc *OrderUseCase) TransitionOrder(ctx context.Context, id uint, to domain.OrderStatus, actor domain.Actor, reason string) (*domain.Order, error) {
 	if id == 0 {
-		return errors.New("invalid order ID")
+		return nil, errInvalidOrderID
+	}
+	if !to.Valid() {
+		return nil, domain.NewValidationError("invalid_order_status", "invalid order status")
+	}
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxOrderReasonLength {
+		return nil, domain.NewValidationError("reason_too_long", "reason must be at most "+strconv.Itoa(maxOrderReasonLength)+" characters")
 	}
 
-	// Validate status
-	switch status {
-	case domain.OrderStatusPending, domain.OrderStatusConfirmed, domain.OrderStatusShipped, domain.OrderStatusDelivered, domain.OrderStatusCancelled:
-		// Valid status
-	default:
-		return errors.New("invalid order status")
+	var order *domain.Order
+	err := uc.UoW.Do(ctx, func(repos domain.Repositories) error {
+		var err error
+		if order, err = repos.Orders.GetByID(id); err != nil {
+			return err
+		}
+		from := order.Status
+		if !from.CanTransitionTo(to) {
+			return domain.NewConflictError("invalid_order_transition", "an order cannot go from "+string(from)+" to "+string(to))
+		}
+		// The status check in TransitionStatus catches a concurrent change
+		// made after the order was read
+		if err := repos.Orders.TransitionStatus(id, from, to); err != nil {
+			return err
+		}
+
+		change := &domain.OrderStatusChange{
+			OrderID:   id,
+			From:      from,
+			To:        to,
+			ActorRole: actor.Role,
+			Reason:    reason,
+		}
+		if actor.UserID != 0 {
+			change.ActorID = &actor.UserID
+		}
+		for _, hook := range uc.hooks[to] {
+			if err := hook(repos, order, change); err != nil {
+				return err
+			}
+		}
+		if err := repos.Orders.AddHistory(change); err != nil {
+			return err
+		}
+		order.Status = to
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return order, nil
+}
 
-	return uc.OrderRepo.UpdateStatus(id, status)
+// CancelOrder lets the owner of an order cancel it before it ships. The
+// stock goes back through the cancellation hook, in the same transaction.
+func (uc *OrderUseCase) CancelOrder(ctx context.Context, actor domain.Actor, id uint, reason string) (*domain.Order, error) {
+	order, err := uc.GetUserOrder(actor.UserID, id)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, domain.NewValidationError("reason_required", "a cancellation reason is required")
+	}
+	if !order.Status.CanTransitionTo(domain.OrderStatusCancelled) {
+		return nil, domain.NewConflictError("order_not_cancellable", "only pending or confirmed orders can be cancelled")
+	}
+	return uc.TransitionOrder(ctx, id, domain.OrderStatusCancelled, actor, reason)
+}
+
+// GetOrderHistory returns the status changes of an order to its owner or to staff.
+func (uc *OrderUseCase) GetOrderHistory(actor domain.Actor, id uint) ([]*domain.OrderStatusChange, error) {
+	order, err := uc.GetOrderByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != actor.UserID && !actor.IsStaff() {
+		return nil, domain.NewForbiddenError("order_forbidden", "access denied")
+	}
+	return uc.OrderRepo.GetHistory(id)
 }
 
 func (uc *OrderUseCase) GetAllOrders() ([]*domain.Order, error) {
@@ -0,0 +1,134 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/repository/postgres"
+	"my-go-project/internal/usecase"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	pgdriver "gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the database in TEST_DATABASE_URL, skipping the
+// test when it is not set.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(pgdriver.Open(dsn), &gorm.Config{
+		TranslateError: true,
+		Logger:         logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	err = db.AutoMigrate(
+		&domain.Product{},
+		&domain.ProductVariant{},
+		&domain.ProductPrice{},
+		&domain.Cart{},
+		&domain.CartItem{},
+		&domain.Order{},
+		&domain.OrderItem{},
+		&domain.OrderStatusChange{},
+	)
+	if err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+// TestCreateOrderConcurrentCheckout has more buyers than stock check out at
+// once: exactly as many orders as there is stock must go through, and the
+// stock must end at zero rather than below it.
+func TestCreateOrderConcurrentCheckout(t *testing.T) {
+	const (
+		buyers = 20
+		stock  = 7
+	)
+	db := openTestDB(t)
+	productRepo := postgres.NewProductRepository(db)
+	cartRepo := postgres.NewCartRepository(db)
+	uc := usecase.NewOrderUseCase(postgres.NewOrderRepository(db), cartRepo, productRepo, postgres.NewUnitOfWork(db))
+
+	product := &domain.Product{Name: "Concurrent checkout", Price: 10, Stock: stock, Status: domain.ProductStatusActive}
+	if err := db.Omit("Variants", "Images", "Attributes").Create(product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+
+	// User IDs no real user is likely to have, so carts and orders don't clash
+	base := uint(time.Now().UnixNano()%1_000_000) + 1_000_000_000
+	userIDs := make([]uint, buyers)
+	for i := range userIDs {
+		userIDs[i] = base + uint(i)
+		if err := cartRepo.CreateCart(userIDs[i]); err != nil {
+			t.Fatalf("create cart: %v", err)
+		}
+		cart, err := cartRepo.GetByUserID(userIDs[i])
+		if err != nil {
+			t.Fatalf("get cart: %v", err)
+		}
+		if err := cartRepo.AddItem(cart.ID, product.ID, 0, 1); err != nil {
+			t.Fatalf("add item: %v", err)
+		}
+	}
+	t.Cleanup(func() {
+		db.Exec("DELETE FROM order_status_changes WHERE order_id IN (SELECT id FROM orders WHERE user_id IN ?)", userIDs)
+		db.Exec("DELETE FROM order_items WHERE product_id = ?", product.ID)
+		db.Exec("DELETE FROM orders WHERE user_id IN ?", userIDs)
+		db.Exec("DELETE FROM cart_items WHERE cart_id IN (SELECT id FROM carts WHERE user_id IN ?)", userIDs)
+		db.Exec("DELETE FROM carts WHERE user_id IN ?", userIDs)
+		db.Exec("DELETE FROM product_prices WHERE product_id = ?", product.ID)
+		db.Exec("DELETE FROM products WHERE id = ?", product.ID)
+	})
+
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		errs      = make(chan error, buyers)
+		succeeded = make(chan uint, buyers)
+	)
+	for _, userID := range userIDs {
+		wg.Add(1)
+		go func(userID uint) {
+			defer wg.Done()
+			<-start
+			order, err := uc.CreateOrder(context.Background(), userID, "1 Test Street")
+			if err != nil {
+				errs <- err
+				return
+			}
+			succeeded <- order.ID
+		}(userID)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+	close(succeeded)
+
+	for err := range errs {
+		if !errors.Is(err, domain.ErrInsufficientStock) {
+			t.Errorf("checkout failed with %v, want insufficient stock", err)
+		}
+	}
+	if n := len(succeeded); n != stock {
+		t.Errorf("%d orders succeeded, want %d", n, stock)
+	}
+
+	var remaining int
+	if err := db.Model(&domain.Product{}).Where("id = ?", product.ID).Select("stock").Scan(&remaining).Error; err != nil {
+		t.Fatalf("read stock: %v", err)
+	}
+	if remaining != 0 {
+		t.Errorf("stock ended at %d, want 0", remaining)
+	}
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"log"
+	"my-go-project/internal/domain"
+	"time"
+)
+
+type PriceUseCase struct {
+	Repo        domain.PriceRepository
+	ProductRepo domain.ProductRepository
+}
+
+func NewPriceUseCase(r domain.PriceRepository, productRepo domain.ProductRepository) *PriceUseCase {
+	return &PriceUseCase{Repo: r, ProductRepo: productRepo}
+}
+
+// GetPriceTimeline returns every price the product has had, oldest first,
+// followed by the scheduled prices still to come.
+func (uc *PriceUseCase) GetPriceTimeline(productID uint) ([]*domain.ProductPrice, error) {
+	if productID == 0 {
+		return nil, errInvalidProductID
+	}
+	if _, err := uc.ProductRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+	return uc.Repo.Timeline(productID)
+}
+
+// SchedulePrice plans a price change that the scheduler applies once
+// effectiveAt has passed.
+func (uc *PriceUseCase) SchedulePrice(productID uint, price float64, effectiveAt time.Time) (*domain.ProductPrice, error) {
+	if productID == 0 {
+		return nil, errInvalidProductID
+	}
+	if price <= 0 {
+		return nil, domain.NewValidationError("invalid_product_price", "product price must be greater than 0")
+	}
+	if !effectiveAt.After(time.Now()) {
+		return nil, domain.NewValidationError("effective_at_in_past", "scheduled prices must take effect in the future")
+	}
+	if _, err := uc.ProductRepo.GetByID(productID); err != nil {
+		return nil, err
+	}
+
+	entry := &domain.ProductPrice{ProductID: productID, Price: price, EffectiveAt: effectiveAt.UTC()}
+	if err := uc.Repo.Schedule(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (uc *PriceUseCase) CancelScheduledPrice(productID, priceID uint) error {
+	if productID == 0 {
+		return errInvalidProductID
+	}
+	if priceID == 0 {
+		return domain.NewValidationError("invalid_price_id", "invalid price ID")
+	}
+	return uc.Repo.Cancel(productID, priceID)
+}
+
+// ApplyDuePrices applies every scheduled price whose time has come.
+func (uc *PriceUseCase) ApplyDuePrices() (int, error) {
+	return uc.Repo.ApplyDue(time.Now())
+}
+
+// RunScheduler applies due prices every interval until the process exits.
+// Running it on several instances is safe.
+func (uc *PriceUseCase) RunScheduler(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		changed, err := uc.ApplyDuePrices()
+		if err != nil {
+			log.Printf("Failed to apply scheduled prices: %v", err)
+			continue
+		}
+		if changed > 0 {
+			log.Printf("Applied scheduled prices to %d products", changed)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package usecase
+
+import (
+	"errors"
+	"log"
+	"my-go-project/internal/domain"
+	"strconv"
+)
+
+// importBatchSize is how many rows are written per transaction. A failing
+// batch is rolled back on its own, so earlier batches stay imported.
+const importBatchSize = 200
+
+// ImportProducts validates every row with the same rules as CreateProduct
+// and upserts the valid ones by SKU. Rows are reported by line, so one bad
+// row does not stop the rest of the file.
+func (uc *ProductUseCase) ImportProducts(rows []domain.ProductImportRow) (*domain.ImportReport, error) {
+	report := &domain.ImportReport{Total: len(rows), Errors: []domain.ImportError{}}
+	categories := map[string]*domain.Category{}
+	seen := map[string]int{}
+
+	fail := func(row domain.ProductImportRow, errs ...domain.ImportError) {
+		report.Failed++
+		for _, e := range errs {
+			e.Line = row.Line
+			if row.Product != nil && row.Product.SKU != nil {
+				e.SKU = *row.Product.SKU
+			}
+			report.Errors = append(report.Errors, e)
+		}
+	}
+
+	var batch []*domain.Product
+	var batchLines []domain.ProductImportRow
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		created, updated, archived, err := uc.Repo.UpsertBySKU(batch)
+		if err != nil {
+			log.Printf("Failed to import batch starting at line %d: %v", batchLines[0].Line, err)
+			for _, row := range batchLines {
+				fail(row, domain.ImportError{Code: "batch_failed", Message: "row could not be saved"})
+			}
+		} else {
+			report.Created += created
+			report.Updated += updated
+			if len(archived) > 0 {
+				isArchived := make(map[string]bool, len(archived))
+				for _, sku := range archived {
+					isArchived[sku] = true
+				}
+				for _, row := range batchLines {
+					if isArchived[*row.Product.SKU] {
+						fail(row, domain.ImportError{Field: "sku", Code: "product_archived", Message: "belongs to an archived product; restore it first"})
+					}
+				}
+			}
+		}
+		batch, batchLines = nil, nil
+	}
+
+	for _, row := range rows {
+		if len(row.Errors) > 0 {
+			fail(row, row.Errors...)
+			continue
+		}
+
+		p := row.Product
+		// Archiving goes through ArchiveProduct, so an import only sets draft
+		// or active. Without a status, existing products keep theirs.
+		if p.Status == domain.ProductStatusArchived {
+			fail(row, domain.ImportError{Field: "status", Code: "invalid_status", Message: "must be draft or active"})
+			continue
+		}
+		keepStatus := p.Status == ""
+		if err := validateProduct(p); err != nil {
+			fail(row, importErrorFrom(err))
+			continue
+		}
+		if keepStatus {
+			p.Status = ""
+		}
+		if p.SKU == nil {
+			fail(row, domain.ImportError{Field: "sku", Code: "required", Message: "is required"})
+			continue
+		}
+		if first, ok := seen[*p.SKU]; ok {
+			fail(row, domain.ImportError{Field: "sku", Code: "duplicate_sku", Message: "repeats the SKU of line " + strconv.Itoa(first)})
+			continue
+		}
+		seen[*p.SKU] = row.Line
+
+		if err := uc.assignImportCategory(p, categories); err != nil {
+			var derr *domain.Error
+			if !errors.As(err, &derr) {
+				return nil, err
+			}
+			fail(row, importErrorFrom(err))
+			continue
+		}
+
+		batch = append(batch, p)
+		batchLines = append(batchLines, row)
+		if len(batch) == importBatchSize {
+			flush()
+		}
+	}
+	flush()
+
+	return report, nil
+}
+
+// ExportProducts calls fn with every product, a batch at a time. The
+// category of each product is replaced by its slug, which is what the
+// importer reads back.
+func (uc *ProductUseCase) ExportProducts(fn func([]*domain.Product) error) error {
+	all, err := uc.CategoryRepo.GetAll()
+	if err != nil {
+		return err
+	}
+	slugs := make(map[uint]string, len(all))
+	for _, c := range all {
+		slugs[c.ID] = c.Slug
+	}
+
+	return uc.Repo.ForEachBatch(importBatchSize, func(products []*domain.Product) error {
+		for _, p := range products {
+			if p.CategoryID != nil {
+				p.Category = slugs[*p.CategoryID]
+			}
+		}
+		return fn(products)
+	})
+}
+
+// assignImportCategory is assignCategory with a per-import cache, since a
+// file usually repeats a handful of categories on every row.
+func (uc *ProductUseCase) assignImportCategory(p *domain.Product, cache map[string]*domain.Category) error {
+	slug := domain.Slugify(p.Category)
+	if slug == "" {
+		p.CategoryID = nil
+		p.Category = ""
+		return nil
+	}
+	category, ok := cache[slug]
+	if !ok {
+		var err error
+		category, err = uc.CategoryRepo.GetBySlug(slug)
+		if err != nil && !errors.Is(err, domain.ErrNotFound) {
+			return err
+		}
+		cache[slug] = category
+	}
+	if category == nil {
+		return domain.NewValidationError("unknown_category", "category does not exist")
+	}
+	p.CategoryID = &category.ID
+	p.Category = category.Name
+	return nil
+}
+
+// importErrorFrom turns a domain error into a row error. Field names are
+// only known for struct validation, which the delivery layer reports itself.
+func importErrorFrom(err error) domain.ImportError {
+	var derr *domain.Error
+	if errors.As(err, &derr) {
+		return domain.ImportError{Code: derr.Code, Message: derr.Message}
+	}
+	return domain.ImportError{Code: "invalid_row", Message: err.Error()}
+}
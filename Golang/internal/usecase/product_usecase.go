@@ -7,22 +7,21 @@ import (
 )
 
 type ProductUseCase struct {
-	Repo domain.ProductRepository
+	Repo          domain.ProductRepository
+	CategoryRepo  domain.CategoryRepository
+	AttributeRepo domain.AttributeRepository
 }
 
-func NewProductUseCase(r domain.ProductRepository) *ProductUseCase {
-	return &ProductUseCase{Repo: r}
+func NewProductUseCase(r domain.ProductRepository, categoryRepo domain.CategoryRepository, attributeRepo domain.AttributeRepository) *ProductUseCase {
+	return &ProductUseCase{Repo: r, CategoryRepo: categoryRepo, AttributeRepo: attributeRepo}
 }
 
 func (uc *ProductUseCase) CreateProduct(p *domain.Product) error {
-	if p.Name == "" {
-		return errors.New("product name is required")
-	}
-	if p.Price <= 0 {
-		return errors.New("product price must be greater than 0")
+	if err := validateProduct(p); err != nil {
+		return err
 	}
-	if p.Stock < 0 {
-		return errors.New("product stock cannot be negative")
+	if err := uc.assignCategory(p); err != nil {
+		return err
 	}
 
 	return uc.Repo.Create(p)
@@ -30,55 +29,317 @@ func (uc *ProductUseCase) CreateProduct(p *domain.Product) error {
 
 func (uc *ProductUseCase) GetProductByID(id uint) (*domain.Product, error) {
 	if id == 0 {
-		return nil, errors.New("invalid product ID")
+		return nil, errInvalidProductID
 	}
 
 	return uc.Repo.GetByID(id)
 }
 
-func (uc *ProductUseCase) GetAllProducts() ([]*domain.Product, error) {
-	return uc.Repo.GetAll()
+// GetActiveProduct is GetProductByID for the storefront: drafts and
+// archived products are reported as not found.
+func (uc *ProductUseCase) GetActiveProduct(id uint) (*domain.Product, error) {
+	p, err := uc.GetProductByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if p.Status != domain.ProductStatusActive {
+		return nil, domain.NewNotFoundError("product_not_found", "product not found")
+	}
+	return p, nil
 }
 
-func (uc *ProductUseCase) UpdateProduct(p *domain.Product) error {
-	if p.ID == 0 {
-		return errors.New("invalid product ID")
+const (
+	defaultProductPageSize = 20
+	maxProductPageSize     = 100
+)
+
+// ListProducts returns one page of products, by default in id order. A
+// listing of one category also gets a facet per attribute of the category.
+func (uc *ProductUseCase) ListProducts(filter domain.ProductFilter) (*domain.ProductPage, error) {
+	attributes, err := uc.normalizeFilter(&filter)
+	if err != nil {
+		return nil, err
+	}
+	if filter.Sort == "" {
+		filter.Sort = domain.ProductSortID
 	}
+	if !filter.Sort.Valid() {
+		return nil, domain.NewValidationError("invalid_sort", "products can be sorted by id, price, name or created_at")
+	}
+
+	page, err := uc.Repo.List(filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(attributes) > 0 {
+		if page.Facets, err = uc.AttributeRepo.Facets(filter, attributes); err != nil {
+			return nil, err
+		}
+	}
+	return page, nil
+}
+
+// SearchProducts runs a full-text search, best match first. Filters and
+// limit/offset work as in ListProducts.
+func (uc *ProductUseCase) SearchProducts(query string, filter domain.ProductFilter) (*domain.ProductSearchPage, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, domain.NewValidationError("search_term_required", "search term is required")
+	}
+	if _, err := uc.normalizeFilter(&filter); err != nil {
+		return nil, err
+	}
+
+	return uc.Repo.Search(query, filter)
+}
+
+const (
+	defaultSuggestLimit = 10
+	maxSuggestLimit     = 20
+)
+
+// SuggestProducts returns autocomplete candidates for what the user has
+// typed so far, tolerating typos.
+func (uc *ProductUseCase) SuggestProducts(text string, limit int) ([]*domain.ProductSuggestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, domain.NewValidationError("search_term_required", "search term is required")
+	}
+	if limit <= 0 {
+		limit = defaultSuggestLimit
+	}
+	if limit > maxSuggestLimit {
+		limit = maxSuggestLimit
+	}
+
+	return uc.Repo.Suggest(text, limit)
+}
+
+// normalizeFilter fills in the default page size, resolves the category slug
+// into the category and its descendants, and rejects filters that cannot
+// match anything sensible. It returns the attributes of the category, which
+// attribute filters are resolved against.
+func (uc *ProductUseCase) normalizeFilter(filter *domain.ProductFilter) ([]*domain.Attribute, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultProductPageSize
+	}
+	if filter.Limit > maxProductPageSize {
+		filter.Limit = maxProductPageSize
+	}
+	if filter.Offset < 0 {
+		return nil, domain.NewValidationError("invalid_offset", "offset cannot be negative")
+	}
+	if filter.Status != "" && !filter.Status.Valid() {
+		return nil, errInvalidProductStatus
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return nil, domain.NewValidationError("invalid_price_range", "min_price cannot be greater than max_price")
+	}
+	filter.Name = strings.ToLower(strings.TrimSpace(filter.Name))
+
+	slug := domain.Slugify(filter.Category)
+	if slug == "" {
+		if len(filter.Attributes) > 0 {
+			return nil, domain.NewValidationError("category_required", "attribute filters need a category")
+		}
+		return nil, nil
+	}
+	category, err := uc.CategoryRepo.GetBySlug(slug)
+	if err != nil {
+		return nil, err
+	}
+	if filter.CategoryIDs, err = uc.CategoryRepo.DescendantIDs(category.ID); err != nil {
+		return nil, err
+	}
+	attributes, err := categoryAttributes(uc.CategoryRepo, uc.AttributeRepo, category.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := resolveAttributeFilters(filter.Attributes, attributes); err != nil {
+		return nil, err
+	}
+	return attributes, nil
+}
+
+// validateProduct holds the rules every created, updated or imported
+// product must follow.
+func validateProduct(p *domain.Product) error {
 	if p.Name == "" {
-		return errors.New("product name is required")
+		return domain.NewValidationError("product_name_required", "product name is required")
 	}
 	if p.Price <= 0 {
-		return errors.New("product price must be greater than 0")
+		return domain.NewValidationError("invalid_product_price", "product price must be greater than 0")
 	}
 	if p.Stock < 0 {
-		return errors.New("product stock cannot be negative")
+		return domain.NewValidationError("invalid_product_stock", "product stock cannot be negative")
+	}
+	// Archiving goes through ArchiveProduct, and archived products must be
+	// restored before they can be edited; ProductRepository.Update checks
+	// the stored status
+	switch p.Status {
+	case "":
+		p.Status = domain.ProductStatusActive
+	case domain.ProductStatusArchived:
+		return domain.NewConflictError("product_archived", "archived products cannot be saved; restore the product first")
+	case domain.ProductStatusDraft, domain.ProductStatusActive:
+	default:
+		return errInvalidProductStatus
+	}
+	if p.SKU != nil {
+		sku := strings.TrimSpace(*p.SKU)
+		if sku == "" {
+			p.SKU = nil
+		} else {
+			p.SKU = &sku
+		}
+	}
+	return nil
+}
+
+// assignCategory checks the product's category and copies its name onto
+// the product. Older clients send only the category name, which is looked
+// up by slug.
+func (uc *ProductUseCase) assignCategory(p *domain.Product) error {
+	var category *domain.Category
+	var err error
+	switch {
+	case p.CategoryID != nil:
+		category, err = uc.CategoryRepo.GetByID(*p.CategoryID)
+	case strings.TrimSpace(p.Category) != "":
+		category, err = uc.CategoryRepo.GetBySlug(domain.Slugify(p.Category))
+	default:
+		p.Category = ""
+		return nil
+	}
+	if errors.Is(err, domain.ErrNotFound) {
+		return domain.NewValidationError("unknown_category", "category does not exist")
+	}
+	if err != nil {
+		return err
+	}
+	p.CategoryID = &category.ID
+	p.Category = category.Name
+	return nil
+}
+
+// UpdateProduct saves p if it is still at p.Version, the version the client
+// last read.
+func (uc *ProductUseCase) UpdateProduct(p *domain.Product) error {
+	if p.ID == 0 {
+		return errInvalidProductID
+	}
+	if err := validateProduct(p); err != nil {
+		return err
+	}
+	if err := uc.assignCategory(p); err != nil {
+		return err
 	}
 
 	return uc.Repo.Update(p)
 }
 
-func (uc *ProductUseCase) DeleteProduct(id uint) error {
+// ArchiveProduct is the soft delete behind DELETE /v1/products/:id.
+func (uc *ProductUseCase) ArchiveProduct(id uint) error {
+	if id == 0 {
+		return errInvalidProductID
+	}
+
+	return uc.Repo.Archive(id)
+}
+
+func (uc *ProductUseCase) RestoreProduct(id uint) (*domain.Product, error) {
 	if id == 0 {
-		return errors.New("invalid product ID")
+		return nil, errInvalidProductID
+	}
+	if err := uc.Repo.Restore(id); err != nil {
+		return nil, err
+	}
+
+	return uc.Repo.GetByID(id)
+}
+
+func (uc *ProductUseCase) GetVariants(productID uint) ([]*domain.ProductVariant, error) {
+	if _, err := uc.GetProductByID(productID); err != nil {
+		return nil, err
 	}
+	return uc.Repo.GetVariants(productID)
+}
 
-	return uc.Repo.Delete(id)
+func (uc *ProductUseCase) CreateVariant(v *domain.ProductVariant) error {
+	if _, err := uc.GetProductByID(v.ProductID); err != nil {
+		return err
+	}
+	if err := validateVariant(v); err != nil {
+		return err
+	}
+	return uc.Repo.CreateVariant(v)
 }
 
-func (uc *ProductUseCase) GetProductsByCategory(category string) ([]*domain.Product, error) {
-	if category == "" {
-		return nil, errors.New("category is required")
+func (uc *ProductUseCase) UpdateVariant(v *domain.ProductVariant) error {
+	if v.ID == 0 {
+		return errInvalidVariantID
+	}
+	if err := validateVariant(v); err != nil {
+		return err
 	}
+	return uc.Repo.UpdateVariant(v)
+}
 
-	return uc.Repo.GetByCategory(category)
+func (uc *ProductUseCase) DeleteVariant(productID, variantID uint) error {
+	if productID == 0 {
+		return errInvalidProductID
+	}
+	if variantID == 0 {
+		return errInvalidVariantID
+	}
+	return uc.Repo.DeleteVariant(productID, variantID)
 }
 
-func (uc *ProductUseCase) SearchProductsByName(name string) ([]*domain.Product, error) {
-	if name == "" {
-		return nil, errors.New("search term is required")
+func validateVariant(v *domain.ProductVariant) error {
+	v.SKU = strings.TrimSpace(v.SKU)
+	if v.SKU == "" {
+		return domain.NewValidationError("sku_required", "SKU is required")
 	}
+	if v.Price != nil && *v.Price <= 0 {
+		return domain.NewValidationError("invalid_variant_price", "variant price must be greater than 0")
+	}
+	if v.Stock < 0 {
+		return domain.NewValidationError("invalid_variant_stock", "variant stock cannot be negative")
+	}
+	return nil
+}
+
+// resolveVariant picks the variant a cart or order line refers to, after
+// checking the product is on sale. Products with variants must be bought
+// through one; products without take variantID 0 and get a nil variant.
+func resolveVariant(p *domain.Product, variantID uint) (*domain.ProductVariant, error) {
+	if p.Status != domain.ProductStatusActive {
+		return nil, domain.NewConflictError("product_unavailable", "product is not available: "+p.Name)
+	}
+	if variantID == 0 {
+		if len(p.Variants) > 0 {
+			return nil, domain.NewValidationError("variant_required", "choose a variant of product: "+p.Name)
+		}
+		return nil, nil
+	}
+	v := p.Variant(variantID)
+	if v == nil {
+		return nil, domain.NewNotFoundError("variant_not_found", "variant not found")
+	}
+	return v, nil
+}
 
-	// Convert to lowercase for case-insensitive search
-	searchTerm := strings.ToLower(strings.TrimSpace(name))
-	return uc.Repo.SearchByName(searchTerm)
+// checkStock fails unless quantity units of the product, or of its variant
+// when v is set, are in stock.
+func checkStock(p *domain.Product, v *domain.ProductVariant, quantity int) error {
+	if v != nil {
+		if v.Stock < quantity {
+			return domain.NewInsufficientStockError("insufficient_stock", "insufficient stock for variant: "+v.SKU)
+		}
+		return nil
+	}
+	if p.Stock < quantity {
+		return domain.NewInsufficientStockError("insufficient_stock", "insufficient stock for product: "+p.Name)
+	}
+	return nil
 }
@@ -0,0 +1,202 @@
+package usecase
+
+import (
+	"context"
+	"my-go-project/internal/domain"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errInvalidReturnID = domain.NewValidationError("invalid_return_id", "invalid return ID")
+
+type ReturnUseCase struct {
+	Repo domain.ReturnRepository
+	UoW  domain.UnitOfWork
+}
+
+func NewReturnUseCase(r domain.ReturnRepository, uow domain.UnitOfWork) *ReturnUseCase {
+	return &ReturnUseCase{Repo: r, UoW: uow}
+}
+
+// RequestReturn opens a return for some of the items of a delivered order
+// owned by the user. Each order item can only be returned up to the
+// quantity bought, counting earlier returns that were not rejected.
+func (uc *ReturnUseCase) RequestReturn(ctx context.Context, userID, orderID uint, reason string, items []domain.ReturnItem) (*domain.ReturnRequest, error) {
+	if userID == 0 {
+		return nil, errInvalidUserID
+	}
+	if orderID == 0 {
+		return nil, errInvalidOrderID
+	}
+	if len(items) == 0 {
+		return nil, domain.NewValidationError("return_items_required", "a return needs at least one item")
+	}
+
+	ret := &domain.ReturnRequest{
+		OrderID: orderID,
+		UserID:  userID,
+		Status:  domain.ReturnStatusRequested,
+		Reason:  strings.TrimSpace(reason),
+	}
+	err := uc.UoW.Do(ctx, func(repos domain.Repositories) error {
+		order, err := repos.Orders.GetByID(orderID)
+		if err != nil {
+			return err
+		}
+		if order.UserID != userID {
+			return domain.NewForbiddenError("order_forbidden", "access denied")
+		}
+		if order.Status != domain.OrderStatusDelivered {
+			return domain.NewConflictError("order_not_returnable", "only delivered orders can be returned")
+		}
+		returned, err := repos.Returns.ReturnedQuantities(orderID)
+		if err != nil {
+			return err
+		}
+
+		ordered := make(map[uint]*domain.OrderItem, len(order.Items))
+		for i := range order.Items {
+			ordered[order.Items[i].ID] = &order.Items[i]
+		}
+		seen := make(map[uint]bool, len(items))
+		for _, item := range items {
+			orderItem, ok := ordered[item.OrderItemID]
+			if !ok {
+				return domain.NewValidationError("unknown_order_item", "order has no item "+strconv.FormatUint(uint64(item.OrderItemID), 10))
+			}
+			if seen[item.OrderItemID] {
+				return domain.NewValidationError("duplicate_return_item", "each order item can be listed once per return")
+			}
+			seen[item.OrderItemID] = true
+			if item.Quantity <= 0 {
+				return domain.NewValidationError("invalid_quantity", "return quantity must be positive")
+			}
+			if left := orderItem.Quantity - returned[item.OrderItemID]; item.Quantity > left {
+				return domain.NewValidationError("return_quantity_exceeded",
+					"only "+strconv.Itoa(left)+" of order item "+strconv.FormatUint(uint64(item.OrderItemID), 10)+" can still be returned")
+			}
+
+			ret.Items = append(ret.Items, domain.ReturnItem{
+				OrderItemID: item.OrderItemID,
+				Quantity:    item.Quantity,
+				Reason:      strings.TrimSpace(item.Reason),
+			})
+			// Refunds are for the price the item was sold at, not today's
+			ret.RefundAmount += orderItem.Price * float64(item.Quantity)
+		}
+		return repos.Returns.Create(ret)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uc.Repo.GetByID(ret.ID)
+}
+
+func (uc *ReturnUseCase) GetUserReturns(userID uint) ([]*domain.ReturnRequest, error) {
+	if userID == 0 {
+		return nil, errInvalidUserID
+	}
+	return uc.Repo.GetByUserID(userID)
+}
+
+// GetUserReturn returns a return only if it belongs to the user.
+func (uc *ReturnUseCase) GetUserReturn(userID, id uint) (*domain.ReturnRequest, error) {
+	ret, err := uc.GetReturn(id)
+	if err != nil {
+		return nil, err
+	}
+	if ret.UserID != userID {
+		return nil, domain.NewForbiddenError("return_forbidden", "access denied")
+	}
+	return ret, nil
+}
+
+func (uc *ReturnUseCase) GetReturn(id uint) (*domain.ReturnRequest, error) {
+	if id == 0 {
+		return nil, errInvalidReturnID
+	}
+	return uc.Repo.GetByID(id)
+}
+
+func (uc *ReturnUseCase) GetAllReturns(status domain.ReturnStatus) ([]*domain.ReturnRequest, error) {
+	if status != "" && !status.Valid() {
+		return nil, domain.NewValidationError("invalid_return_status", "invalid return status")
+	}
+	return uc.Repo.GetAll(status)
+}
+
+func (uc *ReturnUseCase) ApproveReturn(ctx context.Context, id uint, note string) (*domain.ReturnRequest, error) {
+	return uc.transition(ctx, id, domain.ReturnStatusApproved, note, nil)
+}
+
+func (uc *ReturnUseCase) RejectReturn(ctx context.Context, id uint, note string) (*domain.ReturnRequest, error) {
+	if strings.TrimSpace(note) == "" {
+		return nil, domain.NewValidationError("note_required", "say why the return is rejected")
+	}
+	return uc.transition(ctx, id, domain.ReturnStatusRejected, note, nil)
+}
+
+// ReceiveReturn marks the goods as back in the warehouse. With restock, the
+// returned quantities go back on sale in the same transaction.
+func (uc *ReturnUseCase) ReceiveReturn(ctx context.Context, id uint, restock bool, note string) (*domain.ReturnRequest, error) {
+	return uc.transition(ctx, id, domain.ReturnStatusReceived, note, func(repos domain.Repositories, ret *domain.ReturnRequest) error {
+		now := time.Now()
+		ret.ReceivedAt = &now
+		if !restock {
+			return nil
+		}
+		for _, item := range ret.Items {
+			if err := repos.Products.ReleaseStock(item.OrderItem.ProductID, variantIDOf(item.OrderItem.VariantID), item.Quantity); err != nil {
+				return err
+			}
+		}
+		ret.Restocked = true
+		return nil
+	})
+}
+
+// RefundReturn records that RefundAmount was paid back. Moving the money is
+// up to whoever processes the return.
+func (uc *ReturnUseCase) RefundReturn(ctx context.Context, id uint, note string) (*domain.ReturnRequest, error) {
+	return uc.transition(ctx, id, domain.ReturnStatusRefunded, note, func(_ domain.Repositories, ret *domain.ReturnRequest) error {
+		now := time.Now()
+		ret.RefundedAt = &now
+		return nil
+	})
+}
+
+// transition moves a return to status to if the workflow allows it, running
+// apply in the same transaction to fill in what the step changes. A note
+// replaces the previous one; an empty note keeps it.
+func (uc *ReturnUseCase) transition(ctx context.Context, id uint, to domain.ReturnStatus, note string, apply func(repos domain.Repositories, ret *domain.ReturnRequest) error) (*domain.ReturnRequest, error) {
+	if id == 0 {
+		return nil, errInvalidReturnID
+	}
+
+	var ret *domain.ReturnRequest
+	err := uc.UoW.Do(ctx, func(repos domain.Repositories) error {
+		var err error
+		if ret, err = repos.Returns.GetByID(id); err != nil {
+			return err
+		}
+		from := ret.Status
+		if !from.CanTransitionTo(to) {
+			return domain.NewConflictError("invalid_return_transition", "a return cannot go from "+string(from)+" to "+string(to))
+		}
+		ret.Status = to
+		if note = strings.TrimSpace(note); note != "" {
+			ret.Note = note
+		}
+		if apply != nil {
+			if err := apply(repos, ret); err != nil {
+				return err
+			}
+		}
+		return repos.Returns.Transition(ret, from)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
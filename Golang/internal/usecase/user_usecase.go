@@ -1,20 +1,49 @@
 package usecase
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
 	"my-go-project/internal/domain"
 	"my-go-project/pkg/token"
+	"strings"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	passwordResetTTL     = 1 * time.Hour
+	emailVerificationTTL = 24 * time.Hour
+)
+
+var (
+	errUserNotFound        = domain.NewNotFoundError("user_not_found", "user not found")
+	errInvalidRefreshToken = domain.NewUnauthorizedError("invalid_refresh_token", "invalid or expired refresh token")
+	errRefreshTokenRevoked = domain.NewUnauthorizedError("refresh_token_revoked", "refresh token has been revoked")
+	errInvalidOneTimeToken = domain.NewValidationError("invalid_token", "invalid or expired token")
+)
+
 type UserUsecase struct {
-	Repo domain.UserRepository
+	Repo          domain.UserRepository
+	Tokens        domain.TokenStore
+	LoginAttempts domain.LoginAttemptStore
+	Mailer        domain.Mailer
+	// AppURL is the base of the links put in emails
+	AppURL string
 }
 
-func NewUserUsecase(r domain.UserRepository) *UserUsecase {
-	return &UserUsecase{Repo: r}
+func NewUserUsecase(r domain.UserRepository, tokens domain.TokenStore, loginAttempts domain.LoginAttemptStore, mailer domain.Mailer, appURL string) *UserUsecase {
+	return &UserUsecase{
+		Repo:          r,
+		Tokens:        tokens,
+		LoginAttempts: loginAttempts,
+		Mailer:        mailer,
+		AppURL:        strings.TrimRight(appURL, "/"),
+	}
 }
 
 func (uc *UserUsecase) CreateUser(u *domain.User) error {
@@ -24,7 +53,7 @@ func (uc *UserUsecase) CreateUser(u *domain.User) error {
 		return err
 	}
 	if existing != nil {
-		return errors.New("email already exists")
+		return domain.NewConflictError("email_taken", "email already exists")
 	}
 	// Hash password
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(u.Password), bcrypt.DefaultCost)
@@ -33,7 +62,19 @@ func (uc *UserUsecase) CreateUser(u *domain.User) error {
 	}
 	// Save to database
 	u.Password = string(hashedPassword)
-	return uc.Repo.Create(u)
+	// Roles are only granted by an admin, never at registration
+	u.Role = domain.RoleCustomer
+	u.EmailVerified = false
+	if err := uc.Repo.Create(u); err != nil {
+		return err
+	}
+
+	// The account is usable without a verified email, so a mail failure
+	// must not fail the registration; the user can ask for a new link.
+	if err := uc.SendVerificationEmail(u); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", u.ID, err)
+	}
+	return nil
 }
 func (uc *UserUsecase) GetAllUsers() ([]*domain.User, error) {
 	users, err := uc.Repo.GetAll()
@@ -42,22 +83,327 @@ func (uc *UserUsecase) GetAllUsers() ([]*domain.User, error) {
 	}
 	return users, nil
 }
-func (uc *UserUsecase) LoginUser(u *domain.User) (string, string, error) {
+
+// LoginResult holds either a token pair or, for accounts with two-factor
+// authentication, the short-lived token to complete the login with.
+type LoginResult struct {
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+}
+
+// LoginUser returns domain.ErrInvalidCredentials, *domain.LoginThrottledError
+// or *domain.AccountLockedError when the login is refused.
+func (uc *UserUsecase) LoginUser(u *domain.User, ip string) (*LoginResult, error) {
+	if err := uc.checkLoginAllowed(u.Email, ip); err != nil {
+		return nil, err
+	}
+
 	// Validate user
 	existing, err := uc.Repo.FindByEmail(u.Email)
+	if err != nil {
+		return nil, err
+	}
+	// Check password
+	if existing == nil || bcrypt.CompareHashAndPassword([]byte(existing.Password), []byte(u.Password)) != nil {
+		if err := uc.recordLoginFailure(u.Email, ip); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrInvalidCredentials
+	}
+
+	if err := uc.LoginAttempts.Clear(loginEmailKey(u.Email)); err != nil {
+		return nil, err
+	}
+
+	if existing.TOTPEnabled {
+		mfaToken, err := uc.issueMFAToken(existing.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &LoginResult{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	accessToken, refreshToken, err := uc.issueTokens(existing)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+// RefreshTokens exchanges a refresh token for a new token pair. Each refresh
+// token can be used once; presenting one that was already rotated revokes
+// every session of the user, since it means the token has leaked.
+func (uc *UserUsecase) RefreshTokens(refreshToken string) (string, string, error) {
+	claims, err := token.ParseToken(refreshToken, token.TypeRefresh)
+	if err != nil {
+		return "", "", errInvalidRefreshToken
+	}
+
+	before, err := uc.Tokens.RevokedBefore(claims.UserID)
 	if err != nil {
 		return "", "", err
 	}
-	if existing == nil {
-		return "", "", errors.New("invalid email or password")
+	if before != 0 && claims.IssuedAtMilli() < before {
+		return "", "", errRefreshTokenRevoked
 	}
-	// Check password
-	if err := bcrypt.CompareHashAndPassword([]byte(existing.Password), []byte(u.Password)); err != nil {
-		return "", "", errors.New("invalid email or password")
+
+	active, err := uc.Tokens.ConsumeRefreshToken(claims.UserID, claims.ID)
+	if err != nil {
+		return "", "", err
+	}
+	if !active {
+		if err := uc.Tokens.RevokeAllForUser(claims.UserID, token.RefreshTokenTTL); err != nil {
+			return "", "", err
+		}
+		return "", "", errRefreshTokenRevoked
+	}
+
+	user, err := uc.Repo.FindByID(claims.UserID)
+	if err != nil {
+		return "", "", err
+	}
+	if user == nil {
+		return "", "", errUserNotFound
+	}
+
+	return uc.issueTokens(user)
+}
+
+// Logout revokes the current access token and, if given, its refresh token.
+func (uc *UserUsecase) Logout(userID uint, accessJTI string, accessExp time.Time, refreshToken string) error {
+	if accessJTI != "" {
+		if err := uc.Tokens.RevokeAccessToken(accessJTI, time.Until(accessExp)); err != nil {
+			return err
+		}
+	}
+	if refreshToken == "" {
+		return nil
+	}
+
+	claims, err := token.ParseToken(refreshToken, token.TypeRefresh)
+	if err != nil {
+		return errInvalidRefreshToken
+	}
+	if claims.UserID != userID {
+		return domain.NewForbiddenError("refresh_token_forbidden", "refresh token does not belong to user")
+	}
+	_, err = uc.Tokens.ConsumeRefreshToken(claims.UserID, claims.ID)
+	return err
+}
+
+// LogoutAll revokes every access and refresh token issued to the user.
+func (uc *UserUsecase) LogoutAll(userID uint) error {
+	if userID == 0 {
+		return errInvalidUserID
+	}
+	return uc.Tokens.RevokeAllForUser(userID, token.RefreshTokenTTL)
+}
+
+// ChangeRole grants a role to a user. Existing tokens keep the old role until
+// they are refreshed, so every session is revoked to apply it immediately.
+func (uc *UserUsecase) ChangeRole(userID uint, role domain.Role) error {
+	if !role.Valid() {
+		return domain.NewValidationError("invalid_role", "invalid role")
+	}
+	if _, err := uc.GetProfile(userID); err != nil {
+		return err
+	}
+	if err := uc.Repo.UpdateRole(userID, role); err != nil {
+		return err
+	}
+	return uc.Tokens.RevokeAllForUser(userID, token.RefreshTokenTTL)
+}
+
+// BootstrapAdmin makes sure an admin account exists for the given email. It
+// creates the account if there is none. An existing user is only promoted
+// when the password matches theirs and the email is verified, so signing up
+// first with the address is not enough to become admin. It is safe to run
+// on every start.
+func (uc *UserUsecase) BootstrapAdmin(email, password string) error {
+	existing, err := uc.Repo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		if existing.Role == domain.RoleAdmin {
+			return nil
+		}
+		if password == "" || bcrypt.CompareHashAndPassword([]byte(existing.Password), []byte(password)) != nil {
+			return errors.New("a user with the admin email already exists and the admin password does not match theirs")
+		}
+		if !existing.EmailVerified {
+			return errors.New("a user with the admin email already exists but has not verified the email")
+		}
+		return uc.Repo.UpdateRole(existing.ID, domain.RoleAdmin)
+	}
+	if password == "" {
+		return errors.New("password is required to create the admin user")
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	return uc.Repo.Create(&domain.User{
+		Email:         email,
+		Password:      string(hashedPassword),
+		Role:          domain.RoleAdmin,
+		EmailVerified: true,
+	})
+}
+
+// RequestPasswordReset mails a reset link if the email belongs to a user.
+// Unknown emails are silently ignored so the endpoint can't be used to
+// find out which emails are registered.
+func (uc *UserUsecase) RequestPasswordReset(email string) error {
+	user, err := uc.Repo.FindByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	raw, err := uc.newOneTimeToken(domain.TokenPurposePasswordReset, user.ID, passwordResetTTL)
+	if err != nil {
+		return err
+	}
+	return uc.Mailer.Send(domain.MailMessage{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use the link below to choose a new password. It expires in %s.\n\n%s/reset-password?token=%s\n\nIf you did not ask for this, you can ignore this email.",
+			passwordResetTTL, uc.AppURL, raw),
+	})
+}
+
+// ResetPassword sets a new password using a reset token and logs the user
+// out everywhere.
+func (uc *UserUsecase) ResetPassword(rawToken, newPassword string) error {
+	if newPassword == "" {
+		return domain.NewValidationError("password_required", "password is required")
+	}
+	userID, err := uc.Tokens.ConsumeOneTimeToken(domain.TokenPurposePasswordReset, hashToken(rawToken))
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return errInvalidOneTimeToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+	if err := uc.Repo.UpdatePassword(userID, string(hashedPassword)); err != nil {
+		return err
+	}
+	return uc.Tokens.RevokeAllForUser(userID, token.RefreshTokenTTL)
+}
+
+func (uc *UserUsecase) SendVerificationEmail(user *domain.User) error {
+	if user.EmailVerified {
+		return domain.NewConflictError("email_already_verified", "email already verified")
+	}
+
+	raw, err := uc.newOneTimeToken(domain.TokenPurposeEmailVerification, user.ID, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	return uc.Mailer.Send(domain.MailMessage{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body: fmt.Sprintf("Confirm your email address by opening the link below. It expires in %s.\n\n%s/verify-email?token=%s",
+			emailVerificationTTL, uc.AppURL, raw),
+	})
+}
+
+func (uc *UserUsecase) ResendVerificationEmail(userID uint) error {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	return uc.SendVerificationEmail(user)
+}
+
+func (uc *UserUsecase) VerifyEmail(rawToken string) error {
+	userID, err := uc.Tokens.ConsumeOneTimeToken(domain.TokenPurposeEmailVerification, hashToken(rawToken))
+	if err != nil {
+		return err
+	}
+	if userID == 0 {
+		return errInvalidOneTimeToken
 	}
+	return uc.Repo.MarkEmailVerified(userID)
+}
 
-	accessToken, _ := token.GenerateToken(existing.ID, 15*time.Minute)
-	refreshToken, _ := token.GenerateToken(existing.ID, 7*24*time.Hour)
+// newOneTimeToken stores only the hash of the token, so a leaked Redis
+// dump can't be used to take over accounts.
+func (uc *UserUsecase) newOneTimeToken(purpose string, userID uint, ttl time.Duration) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	raw := hex.EncodeToString(b)
+	if err := uc.Tokens.SaveOneTimeToken(purpose, hashToken(raw), userID, ttl); err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+func hashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func (uc *UserUsecase) issueTokens(user *domain.User) (string, string, error) {
+	accessToken, _, err := token.GenerateToken(user.ID, user.Role, token.TypeAccess, token.AccessTokenTTL)
+	if err != nil {
+		return "", "", err
+	}
+	refreshToken, jti, err := token.GenerateToken(user.ID, "", token.TypeRefresh, token.RefreshTokenTTL)
+	if err != nil {
+		return "", "", err
+	}
+	if err := uc.Tokens.SaveRefreshToken(user.ID, jti, token.RefreshTokenTTL); err != nil {
+		return "", "", err
+	}
 
 	return accessToken, refreshToken, nil
 }
+
+func (uc *UserUsecase) GetProfile(userID uint) (*domain.User, error) {
+	if userID == 0 {
+		return nil, errInvalidUserID
+	}
+	user, err := uc.Repo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, errUserNotFound
+	}
+	return user, nil
+}
+
+// UpdateProfile changes only the fields that are non-nil.
+func (uc *UserUsecase) UpdateProfile(userID uint, username, phone, avatar *string) (*domain.User, error) {
+	user, err := uc.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	if username != nil {
+		user.Username = strings.TrimSpace(*username)
+	}
+	if phone != nil {
+		user.Phone = strings.TrimSpace(*phone)
+	}
+	if avatar != nil {
+		user.Avatar = strings.TrimSpace(*avatar)
+	}
+	if err := uc.Repo.Update(user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
@@ -0,0 +1,193 @@
+package usecase_test
+
+import (
+	"errors"
+	"my-go-project/internal/domain"
+	"my-go-project/internal/usecase"
+	"my-go-project/pkg/mailer"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// memUserRepo keeps users in memory; the embedded interface makes any
+// method the tests don't need panic.
+type memUserRepo struct {
+	domain.UserRepository
+	users map[uint]*domain.User
+}
+
+func (r *memUserRepo) FindByEmail(email string) (*domain.User, error) {
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memUserRepo) FindByID(id uint) (*domain.User, error) {
+	return r.users[id], nil
+}
+
+func (r *memUserRepo) UpdatePassword(id uint, passwordHash string) error {
+	r.users[id].Password = passwordHash
+	return nil
+}
+
+func (r *memUserRepo) MarkEmailVerified(id uint) error {
+	r.users[id].EmailVerified = true
+	return nil
+}
+
+// memTokenStore implements the one-time token and revocation parts of
+// domain.TokenStore.
+type memTokenStore struct {
+	domain.TokenStore
+	mu       sync.Mutex
+	oneTime  map[string]uint
+	revokeAt map[uint]time.Time
+}
+
+func newMemTokenStore() *memTokenStore {
+	return &memTokenStore{oneTime: map[string]uint{}, revokeAt: map[uint]time.Time{}}
+}
+
+func (s *memTokenStore) SaveOneTimeToken(purpose, tokenHash string, userID uint, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.oneTime[purpose+":"+tokenHash] = userID
+	return nil
+}
+
+func (s *memTokenStore) ConsumeOneTimeToken(purpose, tokenHash string) (uint, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	userID := s.oneTime[purpose+":"+tokenHash]
+	delete(s.oneTime, purpose+":"+tokenHash)
+	return userID, nil
+}
+
+func (s *memTokenStore) RevokeAllForUser(userID uint, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeAt[userID] = time.Now()
+	return nil
+}
+
+const testAppURL = "https://shop.example"
+
+func newMailTestUsecase(t *testing.T, users ...*domain.User) (*usecase.UserUsecase, *memUserRepo, *memTokenStore, *mailer.LogMailer) {
+	t.Helper()
+	repo := &memUserRepo{users: map[uint]*domain.User{}}
+	for _, u := range users {
+		repo.users[u.ID] = u
+	}
+	tokens := newMemTokenStore()
+	mail := mailer.NewLogMailer(nil)
+	return usecase.NewUserUsecase(repo, tokens, nil, mail, testAppURL), repo, tokens, mail
+}
+
+// linkToken returns the token of the link to path in the only message sent.
+func linkToken(t *testing.T, mail *mailer.LogMailer, to, path string) string {
+	t.Helper()
+	sent := mail.Sent()
+	if len(sent) != 1 {
+		t.Fatalf("%d messages sent, want 1", len(sent))
+	}
+	if sent[0].To != to {
+		t.Errorf("message sent to %q, want %q", sent[0].To, to)
+	}
+	m := regexp.MustCompile(regexp.QuoteMeta(testAppURL+path) + `\?token=([0-9a-f]+)`).FindStringSubmatch(sent[0].Body)
+	if m == nil {
+		t.Fatalf("message has no %s link:\n%s", path, sent[0].Body)
+	}
+	return m[1]
+}
+
+func TestPasswordResetMailsSingleUseLink(t *testing.T) {
+	uc, repo, tokens, mail := newMailTestUsecase(t, &domain.User{ID: 7, Email: "ann@example.com", Password: "old-hash"})
+
+	if err := uc.RequestPasswordReset(" ann@example.com "); err != nil {
+		t.Fatalf("request reset: %v", err)
+	}
+	if subject := mail.Sent()[0].Subject; subject != "Reset your password" {
+		t.Errorf("subject %q", subject)
+	}
+	raw := linkToken(t, mail, "ann@example.com", "/reset-password")
+
+	if err := uc.ResetPassword(raw, "new-secret-1"); err != nil {
+		t.Fatalf("reset: %v", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(repo.users[7].Password), []byte("new-secret-1")) != nil {
+		t.Error("password was not changed to the new one")
+	}
+	if _, ok := tokens.revokeAt[7]; !ok {
+		t.Error("sessions were not revoked after the reset")
+	}
+
+	err := uc.ResetPassword(raw, "new-secret-2")
+	if !errors.Is(err, domain.ErrValidation) {
+		t.Fatalf("second use of the reset link: got %v, want a validation error", err)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(repo.users[7].Password), []byte("new-secret-1")) != nil {
+		t.Error("second use of the reset link changed the password")
+	}
+}
+
+func TestPasswordResetIgnoresUnknownEmail(t *testing.T) {
+	uc, _, _, mail := newMailTestUsecase(t, &domain.User{ID: 7, Email: "ann@example.com"})
+
+	if err := uc.RequestPasswordReset("nobody@example.com"); err != nil {
+		t.Fatalf("request reset: %v", err)
+	}
+	if sent := mail.Sent(); len(sent) != 0 {
+		t.Errorf("%d messages sent for an unknown email, want 0", len(sent))
+	}
+}
+
+func TestEmailVerificationMailsSingleUseLink(t *testing.T) {
+	user := &domain.User{ID: 9, Email: "bo@example.com"}
+	uc, repo, _, mail := newMailTestUsecase(t, user)
+
+	if err := uc.ResendVerificationEmail(9); err != nil {
+		t.Fatalf("send verification: %v", err)
+	}
+	if subject := mail.Sent()[0].Subject; subject != "Verify your email" {
+		t.Errorf("subject %q", subject)
+	}
+	raw := linkToken(t, mail, "bo@example.com", "/verify-email")
+
+	if err := uc.VerifyEmail(raw); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if !repo.users[9].EmailVerified {
+		t.Error("email was not marked verified")
+	}
+	if err := uc.VerifyEmail(raw); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("second use of the verification link: got %v, want a validation error", err)
+	}
+	if err := uc.ResendVerificationEmail(9); !errors.Is(err, domain.ErrConflict) {
+		t.Errorf("verification for a verified email: got %v, want a conflict", err)
+	}
+}
+
+func TestOneTimeTokensAreBoundToTheirPurpose(t *testing.T) {
+	uc, repo, _, mail := newMailTestUsecase(t, &domain.User{ID: 7, Email: "ann@example.com", Password: "old-hash"})
+
+	if err := uc.ResendVerificationEmail(7); err != nil {
+		t.Fatalf("send verification: %v", err)
+	}
+	raw := linkToken(t, mail, "ann@example.com", "/verify-email")
+
+	if err := uc.ResetPassword(raw, "new-secret-1"); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("reset with a verification token: got %v, want a validation error", err)
+	}
+	if repo.users[7].Password != "old-hash" {
+		t.Error("a verification token changed the password")
+	}
+}
@@ -0,0 +1,66 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type LoginAttemptStore struct {
+	client *redis.Client
+}
+
+func NewLoginAttemptStore(client *redis.Client) *LoginAttemptStore {
+	return &LoginAttemptStore{client: client}
+}
+
+func (s *LoginAttemptStore) IncrFailures(key string, window time.Duration) (int64, error) {
+	ctx := context.Background()
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, "login_failures:"+key)
+	pipe.Expire(ctx, "login_failures:"+key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *LoginAttemptStore) Block(key string, d time.Duration) error {
+	return s.client.Set(context.Background(), "login_block:"+key, 1, d).Err()
+}
+
+func (s *LoginAttemptStore) BlockedFor(key string) (time.Duration, error) {
+	return s.ttl("login_block:" + key)
+}
+
+func (s *LoginAttemptStore) Lock(key string, d time.Duration) error {
+	return s.client.Set(context.Background(), "login_lock:"+key, 1, d).Err()
+}
+
+func (s *LoginAttemptStore) LockedFor(key string) (time.Duration, error) {
+	return s.ttl("login_lock:" + key)
+}
+
+func (s *LoginAttemptStore) Clear(keys ...string) error {
+	var redisKeys []string
+	for _, key := range keys {
+		redisKeys = append(redisKeys, "login_failures:"+key, "login_block:"+key, "login_lock:"+key)
+	}
+	if len(redisKeys) == 0 {
+		return nil
+	}
+	return s.client.Del(context.Background(), redisKeys...).Err()
+}
+
+func (s *LoginAttemptStore) ttl(key string) (time.Duration, error) {
+	d, err := s.client.PTTL(context.Background(), key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// Negative values mean the key is missing or has no expiry
+	if d < 0 {
+		return 0, nil
+	}
+	return d, nil
+}
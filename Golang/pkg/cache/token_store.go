@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type TokenStore struct {
+	client *redis.Client
+}
+
+func NewTokenStore(client *redis.Client) *TokenStore {
+	return &TokenStore{client: client}
+}
+
+func refreshTokenKey(userID uint, jti string) string {
+	return fmt.Sprintf("refresh_token:%d:%s", userID, jti)
+}
+
+func revokedAccessKey(jti string) string {
+	return fmt.Sprintf("revoked_access:%s", jti)
+}
+
+func revokedBeforeKey(userID uint) string {
+	return fmt.Sprintf("revoked_before_ms:%d", userID)
+}
+
+func totpStepKey(userID uint) string {
+	return fmt.Sprintf("totp_step:%d", userID)
+}
+
+// useTOTPStep compares and sets the last accepted step in one round trip,
+// so two requests with the same code cannot both pass.
+var useTOTPStep = redis.NewScript(`
+local last = redis.call("GET", KEYS[1])
+if last and tonumber(last) >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+func oneTimeTokenKey(purpose, tokenHash string) string {
+	return fmt.Sprintf("one_time_token:%s:%s", purpose, tokenHash)
+}
+
+func (s *TokenStore) SaveRefreshToken(userID uint, jti string, ttl time.Duration) error {
+	return s.client.Set(context.Background(), refreshTokenKey(userID, jti), 1, ttl).Err()
+}
+
+func (s *TokenStore) ConsumeRefreshToken(userID uint, jti string) (bool, error) {
+	deleted, err := s.client.Del(context.Background(), refreshTokenKey(userID, jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return deleted == 1, nil
+}
+
+func (s *TokenStore) RevokeAccessToken(jti string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(context.Background(), revokedAccessKey(jti), 1, ttl).Err()
+}
+
+func (s *TokenStore) IsAccessTokenRevoked(jti string) (bool, error) {
+	n, err := s.client.Exists(context.Background(), revokedAccessKey(jti)).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *TokenStore) RevokeAllForUser(userID uint, ttl time.Duration) error {
+	ctx := context.Background()
+	if err := s.client.Set(ctx, revokedBeforeKey(userID), time.Now().UnixMilli(), ttl).Err(); err != nil {
+		return err
+	}
+
+	iter := s.client.Scan(ctx, 0, refreshTokenKey(userID, "*"), 100).Iterator()
+	for iter.Next(ctx) {
+		if err := s.client.Del(ctx, iter.Val()).Err(); err != nil {
+			return err
+		}
+	}
+	return iter.Err()
+}
+
+func (s *TokenStore) RevokedBefore(userID uint) (int64, error) {
+	ctx := context.Background()
+	val, err := s.client.Get(ctx, revokedBeforeKey(userID)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(val, 10, 64)
+}
+
+func (s *TokenStore) UseTOTPStep(userID uint, step int64, ttl time.Duration) (bool, error) {
+	ok, err := useTOTPStep.Run(context.Background(), s.client, []string{totpStepKey(userID)}, step, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return ok == 1, nil
+}
+
+func (s *TokenStore) SaveOneTimeToken(purpose, tokenHash string, userID uint, ttl time.Duration) error {
+	return s.client.Set(context.Background(), oneTimeTokenKey(purpose, tokenHash), userID, ttl).Err()
+}
+
+func (s *TokenStore) ConsumeOneTimeToken(purpose, tokenHash string) (uint, error) {
+	// GETDEL makes sure two concurrent requests cannot both use the token
+	val, err := s.client.GetDel(context.Background(), oneTimeTokenKey(purpose, tokenHash)).Result()
+	if err == redis.Nil {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	userID, err := strconv.ParseUint(val, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(userID), nil
+}
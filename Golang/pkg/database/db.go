@@ -20,7 +20,10 @@ func InitDB() *gorm.DB {
 	dsn := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable",
 		host, user, password, dbname, port)
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		// Lets repositories detect unique violations with gorm.ErrDuplicatedKey
+		TranslateError: true,
+	})
 	if err != nil {
 		log.Fatal("Failed to connect to database:", err)
 	}
@@ -29,10 +32,19 @@ func InitDB() *gorm.DB {
 	err = db.AutoMigrate(
 		&domain.User{},
 		&domain.Product{},
+		&domain.Category{},
+		&domain.ProductVariant{},
+		&domain.ProductImage{},
+		&domain.Attribute{},
+		&domain.ProductAttributeValue{},
+		&domain.ProductPrice{},
 		&domain.Cart{},
 		&domain.CartItem{},
 		&domain.Order{},
 		&domain.OrderItem{},
+		&domain.OrderStatusChange{},
+		&domain.ReturnRequest{},
+		&domain.ReturnItem{},
 		&domain.Review{},
 	)
 	if err != nil {
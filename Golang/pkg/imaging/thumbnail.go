@@ -0,0 +1,69 @@
+// Package imaging makes thumbnails with the standard library only.
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Thumbnail crops the center square of src and scales it to size x size.
+// Downscaling averages every source pixel under each target pixel, which
+// avoids the aliasing of nearest-neighbour sampling.
+func Thumbnail(src image.Image, size int) *image.RGBA {
+	b := src.Bounds()
+	side := b.Dx()
+	if b.Dy() < side {
+		side = b.Dy()
+	}
+	crop := image.Rect(0, 0, side, side)
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+
+	// Work on RGBA so the pixel loop can read Pix directly
+	rgba := image.NewRGBA(crop)
+	draw.Draw(rgba, crop, src, image.Pt(x0, y0), draw.Src)
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	scale := float64(side) / float64(size)
+	for dy := 0; dy < size; dy++ {
+		sy0, sy1 := span(dy, scale, side)
+		for dx := 0; dx < size; dx++ {
+			sx0, sx1 := span(dx, scale, side)
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				row := rgba.Pix[sy*rgba.Stride:]
+				for sx := sx0; sx < sx1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint64(p[0])
+					g += uint64(p[1])
+					bl += uint64(p[2])
+					a += uint64(p[3])
+					n++
+				}
+			}
+			o := dst.PixOffset(dx, dy)
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(bl / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// span returns the source pixels [from, to) covered by target pixel i. It
+// always covers at least one pixel, so upscaling repeats source pixels.
+func span(i int, scale float64, limit int) (int, int) {
+	from := int(float64(i) * scale)
+	to := int(float64(i+1) * scale)
+	if to <= from {
+		to = from + 1
+	}
+	if to > limit {
+		to = limit
+	}
+	if from >= limit {
+		from = limit - 1
+	}
+	return from, to
+}
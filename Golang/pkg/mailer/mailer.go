@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"my-go-project/internal/domain"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+)
+
+// NewFromEnv returns the mailer chosen by MAILER: "smtp", the default, or
+// "log", which writes messages to stdout. Messages carry password reset and
+// verification links, so logging them has to be asked for explicitly, and
+// a missing SMTP_HOST stops the app rather than falling back to it.
+func NewFromEnv() domain.Mailer {
+	switch driver := getEnv("MAILER", "smtp"); driver {
+	case "log":
+		log.Println("MAILER is log, emails will be written to stdout instead of sent")
+		return NewLogMailer(os.Stdout)
+	case "smtp":
+	default:
+		log.Fatalf("Unknown MAILER %q, use smtp or log", driver)
+	}
+
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		log.Fatal("SMTP_HOST is not set; set it, or set MAILER=log to write emails to stdout")
+	}
+	return NewSMTPMailer(
+		host,
+		getEnv("SMTP_PORT", "587"),
+		os.Getenv("SMTP_USERNAME"),
+		os.Getenv("SMTP_PASSWORD"),
+		getEnv("SMTP_FROM", "no-reply@localhost"),
+	)
+}
+
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &SMTPMailer{addr: host + ":" + port, auth: auth, from: from}
+}
+
+func (m *SMTPMailer) Send(msg domain.MailMessage) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", m.from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(msg.Body)
+	return smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, []byte(b.String()))
+}
+
+// LogMailer writes messages to w instead of sending them and keeps a copy of
+// each one, so tests can inspect what would have been sent.
+type LogMailer struct {
+	mu   sync.Mutex
+	w    io.Writer
+	sent []domain.MailMessage
+}
+
+func NewLogMailer(w io.Writer) *LogMailer {
+	return &LogMailer{w: w}
+}
+
+func (m *LogMailer) Send(msg domain.MailMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sent = append(m.sent, msg)
+	if m.w == nil {
+		return nil
+	}
+	_, err := fmt.Fprintf(m.w, "To: %s\nSubject: %s\n\n%s\n---\n", msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+// Sent returns the messages sent so far.
+func (m *LogMailer) Sent() []domain.MailMessage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]domain.MailMessage(nil), m.sent...)
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,66 @@
+package storage
+
+import (
+	"errors"
+	"io"
+	"my-go-project/internal/domain"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestS3RoundTrip runs against the S3-compatible service in the S3_* env
+// vars, e.g. the minio service of docker-compose, and is skipped without
+// them. The bucket must exist.
+func TestS3RoundTrip(t *testing.T) {
+	cfg := S3Config{
+		Endpoint:  os.Getenv("S3_ENDPOINT"),
+		Region:    getEnv("S3_REGION", "us-east-1"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+	}
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		t.Skip("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are not all set")
+	}
+	s, err := NewS3Storage(cfg)
+	if err != nil {
+		t.Fatalf("configure: %v", err)
+	}
+
+	key := "storage-test/" + strconv.FormatInt(time.Now().UnixNano(), 10) + "/image_small.jpg"
+	data := []byte("\xff\xd8\xff\xe0 not really a jpeg")
+	if err := s.Put(key, data, "image/jpeg"); err != nil {
+		t.Fatalf("put: %v", err)
+	}
+	t.Cleanup(func() { s.Delete(key) })
+
+	body, contentType, err := s.Open(key)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	got, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(got) != string(data) {
+		t.Errorf("read back %q, want %q", got, data)
+	}
+	if contentType != "image/jpeg" {
+		t.Errorf("content type %q, want image/jpeg", contentType)
+	}
+
+	if err := s.Delete(key); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, _, err := s.Open(key); !errors.Is(err, domain.ErrFileNotFound) {
+		t.Errorf("open after delete: got %v, want file not found", err)
+	}
+	// Deleting again is not an error, as the image usecase relies on
+	if err := s.Delete(key); err != nil {
+		t.Errorf("second delete: %v", err)
+	}
+}
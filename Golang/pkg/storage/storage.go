@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"log"
+	"my-go-project/internal/domain"
+	"os"
+	"strings"
+)
+
+// NewFromEnv returns S3 storage when STORAGE_DRIVER is "s3", and otherwise
+// storage on the local filesystem under STORAGE_DIR.
+func NewFromEnv() domain.FileStorage {
+	if os.Getenv("STORAGE_DRIVER") == "s3" {
+		s, err := NewS3Storage(S3Config{
+			Endpoint:  getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+			Region:    getEnv("S3_REGION", "us-east-1"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			// MinIO and most self-hosted stores only support path-style URLs
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		})
+		if err != nil {
+			log.Fatal("Failed to configure S3 storage:", err)
+		}
+		return s
+	}
+
+	dir := getEnv("STORAGE_DIR", "./uploads")
+	s, err := NewLocalStorage(dir)
+	if err != nil {
+		log.Fatal("Failed to create storage directory:", err)
+	}
+	return s
+}
+
+// validKey rejects keys that could escape the storage root or need escaping
+// in a URL.
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '/', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -1,18 +1,93 @@
 package token
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"time"
 
-	"my-go-project/internal/common"
+	"my-go-project/internal/domain"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func GenerateToken(userID uint, duration time.Duration) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(duration).Unix(),
+const (
+	TypeAccess  = "access"
+	TypeRefresh = "refresh"
+	// TypeMFAPending proves the password was checked while a second factor is still due
+	TypeMFAPending = "mfa_pending"
+
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+	MFATokenTTL     = 5 * time.Minute
+)
+
+type Claims struct {
+	UserID uint        `json:"user_id"`
+	Role   domain.Role `json:"role,omitempty"`
+	Type   string      `json:"typ"`
+	// IssuedAtMs is iat in milliseconds, fine enough to tell a token from a
+	// revocation made in the same second
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// IssuedAtMilli returns when the token was issued, in Unix milliseconds.
+// Tokens from before iat_ms existed fall back to iat, and tokens without
+// either return 0.
+func (c *Claims) IssuedAtMilli() int64 {
+	if c.IssuedAtMs != 0 {
+		return c.IssuedAtMs
+	}
+	if c.IssuedAt == nil {
+		return 0
+	}
+	return c.IssuedAt.Unix() * 1000
+}
+
+// GenerateToken signs a token of the given type and returns it with its jti.
+func GenerateToken(userID uint, role domain.Role, tokenType string, duration time.Duration) (string, string, error) {
+	jti, err := newJTI()
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
+	claims := Claims{
+		UserID:     userID,
+		Role:       role,
+		Type:       tokenType,
+		IssuedAtMs: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(duration)),
+		},
+	}
+	signed, err := keys.Sign(claims)
+	if err != nil {
+		return "", "", err
+	}
+	return signed, jti, nil
+}
+
+// ParseToken verifies a token and checks that it has the expected type.
+func ParseToken(tokenStr string, tokenType string) (*Claims, error) {
+	claims := &Claims{}
+	token, err := jwt.ParseWithClaims(tokenStr, claims, keys.Keyfunc,
+		jwt.WithValidMethods([]string{AlgHS256, AlgRS256, AlgEdDSA}))
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid or expired token")
+	}
+	if claims.Type != tokenType || claims.ID == "" || claims.UserID == 0 {
+		return nil, errors.New("invalid token type")
+	}
+	return claims, nil
+}
+
+func newJTI() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(common.SecretKey))
+	return hex.EncodeToString(b), nil
 }
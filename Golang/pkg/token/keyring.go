@@ -0,0 +1,261 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"sort"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key is one signing key. Keys without a private part can only verify, which
+// is how retired keys are kept around during a rotation.
+type Key struct {
+	ID        string
+	Algorithm string
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeyRing holds the active signing key and every key still accepted for verification.
+type KeyRing struct {
+	active *Key
+	keys   map[string]*Key
+}
+
+type keyConfig struct {
+	ID             string `json:"kid"`
+	Algorithm      string `json:"alg"`
+	Secret         string `json:"secret"`
+	PrivateKeyFile string `json:"private_key_file"`
+	PublicKeyFile  string `json:"public_key_file"`
+}
+
+type keyRingConfig struct {
+	Active string      `json:"active"`
+	Keys   []keyConfig `json:"keys"`
+}
+
+// JWK is the public part of a key as published in the JWKS document.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+	N         string `json:"n,omitempty"`
+	E         string `json:"e,omitempty"`
+	Curve     string `json:"crv,omitempty"`
+	X         string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+var keys *KeyRing
+
+// InitKeys loads the keyring from JWT_KEYS_FILE, or builds a single HS256
+// key from JWT_SECRET. Without either, an ephemeral key is generated so
+// tokens stop working after a restart.
+func InitKeys() {
+	var err error
+	switch {
+	case os.Getenv("JWT_KEYS_FILE") != "":
+		keys, err = LoadKeyRing(os.Getenv("JWT_KEYS_FILE"))
+	case os.Getenv("JWT_SECRET") != "":
+		keys, err = newKeyRing(keyRingConfig{
+			Active: "default",
+			Keys:   []keyConfig{{ID: "default", Algorithm: AlgHS256, Secret: os.Getenv("JWT_SECRET")}},
+		})
+	default:
+		log.Println("JWT_KEYS_FILE and JWT_SECRET are not set, using an ephemeral signing key")
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			log.Fatal("Failed to generate JWT key:", err)
+		}
+		keys, err = newKeyRing(keyRingConfig{
+			Active: "ephemeral",
+			Keys:   []keyConfig{{ID: "ephemeral", Algorithm: AlgHS256, Secret: string(secret)}},
+		})
+	}
+	if err != nil {
+		log.Fatal("Failed to load JWT keys:", err)
+	}
+	log.Printf("Loaded JWT keyring, active key %q", keys.active.ID)
+}
+
+// LoadKeyRing reads a JSON keyring config of the form
+// {"active": "kid", "keys": [{"kid": ..., "alg": ..., ...}]}.
+func LoadKeyRing(path string) (*KeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cfg keyRingConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("invalid keyring config: %w", err)
+	}
+	return newKeyRing(cfg)
+}
+
+func newKeyRing(cfg keyRingConfig) (*KeyRing, error) {
+	ring := &KeyRing{keys: make(map[string]*Key)}
+	for _, kc := range cfg.Keys {
+		if kc.ID == "" {
+			return nil, errors.New("key is missing kid")
+		}
+		if _, ok := ring.keys[kc.ID]; ok {
+			return nil, fmt.Errorf("duplicate kid %q", kc.ID)
+		}
+		key, err := loadKey(kc)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", kc.ID, err)
+		}
+		ring.keys[kc.ID] = key
+	}
+
+	active, ok := ring.keys[cfg.Active]
+	if !ok {
+		return nil, fmt.Errorf("active key %q not found", cfg.Active)
+	}
+	if active.signKey == nil {
+		return nil, fmt.Errorf("active key %q has no private key", cfg.Active)
+	}
+	ring.active = active
+	return ring, nil
+}
+
+func loadKey(kc keyConfig) (*Key, error) {
+	key := &Key{ID: kc.ID, Algorithm: kc.Algorithm}
+	switch kc.Algorithm {
+	case AlgHS256:
+		if kc.Secret == "" {
+			return nil, errors.New("HS256 key requires a secret")
+		}
+		key.signKey = []byte(kc.Secret)
+		key.verifyKey = []byte(kc.Secret)
+	case AlgRS256:
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseRSAPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.verifyKey = &priv.PublicKey
+		} else if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		} else {
+			return nil, errors.New("RS256 key requires private_key_file or public_key_file")
+		}
+	case AlgEdDSA:
+		if kc.PrivateKeyFile != "" {
+			pem, err := os.ReadFile(kc.PrivateKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			priv, err := jwt.ParseEdPrivateKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.signKey = priv
+			key.verifyKey = priv.(crypto.Signer).Public()
+		} else if kc.PublicKeyFile != "" {
+			pem, err := os.ReadFile(kc.PublicKeyFile)
+			if err != nil {
+				return nil, err
+			}
+			pub, err := jwt.ParseEdPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, err
+			}
+			key.verifyKey = pub
+		} else {
+			return nil, errors.New("EdDSA key requires private_key_file or public_key_file")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", kc.Algorithm)
+	}
+	return key, nil
+}
+
+// Sign signs the claims with the active key and sets the kid header.
+func (r *KeyRing) Sign(claims jwt.Claims) (string, error) {
+	t := jwt.NewWithClaims(jwt.GetSigningMethod(r.active.Algorithm), claims)
+	t.Header["kid"] = r.active.ID
+	return t.SignedString(r.active.signKey)
+}
+
+// Keyfunc picks the verification key by kid and rejects tokens whose
+// algorithm does not match the key.
+func (r *KeyRing) Keyfunc(t *jwt.Token) (interface{}, error) {
+	key := r.active
+	if kid, ok := t.Header["kid"].(string); ok {
+		if key, ok = r.keys[kid]; !ok {
+			return nil, fmt.Errorf("unknown kid %q", kid)
+		}
+	}
+	if t.Method.Alg() != key.Algorithm {
+		return nil, errors.New("unexpected signing algorithm")
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys. HS256 keys are shared secrets and are never published.
+func (r *KeyRing) JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	for _, key := range r.keys {
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "RSA",
+				KeyID:     key.ID,
+				Algorithm: key.Algorithm,
+				Use:       "sig",
+				N:         base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+				E:         base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, JWK{
+				KeyType:   "OKP",
+				KeyID:     key.ID,
+				Algorithm: key.Algorithm,
+				Use:       "sig",
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(pub),
+			})
+		}
+	}
+	sort.Slice(set.Keys, func(i, j int) bool { return set.Keys[i].KeyID < set.Keys[j].KeyID })
+	return set
+}
+
+func JWKS() JWKSet {
+	return keys.JWKS()
+}